@@ -0,0 +1,303 @@
+package core
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/game"
+)
+
+/**
+ * kickRequest:
+ * The struct of the request body to kick a player.
+ *
+ * @property {string} Room 																			- the name of the game room
+ * @property {string} Name																			- the name of the player
+ * @property {string} Reason																		- the reason to kick the player
+ */
+type kickRequest struct {
+	Room string
+	Name string
+	Reason string
+}
+
+/**
+ * banRequest:
+ * The struct of the request body to ban a player name or an ip.
+ *
+ * @property {string} Name 																			- the player name to ban
+ * @property {string} IP																				- the ip to ban
+ * @property {string} Reason																		- the reason of the ban
+ * @property {int} Duration																			- the seconds of the ban, permanent if zero
+ */
+type banRequest struct {
+	Name string
+	IP string
+	Reason string
+	Duration int
+}
+
+/**
+ * broadcastRequest:
+ * The struct of the request body to broadcast a server message.
+ *
+ * @property {string} Room 																			- the name of the game room, all rooms if empty
+ * @property {string} Message																		- the message to broadcast
+ */
+type broadcastRequest struct {
+	Room string
+	Message string
+}
+
+/**
+ * createRoomRequest:
+ * The struct of the request body to create a game room.
+ *
+ * @property {string} Name 																			- the name of the new game room
+ * @property {string} Preset																		- the name of the room preset, used if Config is empty
+ * @property {*game.RoomConfig} Config													- the full settings of the game room
+ */
+type createRoomRequest struct {
+	Name string
+	Preset string
+	Config *game.RoomConfig
+}
+
+/**
+ * <core>.adminCreateRoomHandler:
+ * The function to handle the request to create a game room from a preset or a full config
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func adminCreateRoomHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodPost) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var request createRoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body!", 400)
+		return
+	}
+	if (request.Name == "") {
+		http.Error(w, "Room name can not be empty!", 400)
+		return
+	}
+	var config game.RoomConfig
+	if (request.Config != nil) {
+		config = *request.Config
+	} else {
+		preset, err := game.GetRoomPreset(request.Preset)
+		if (err != nil) {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		config = preset
+	}
+	new_game, err := app.createGame(request.Name, config)
+	if (err != nil) {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	log.Printf("Game room %s created by admin", new_game.Name)
+	writeJSON(w, 201, map[string]interface{} {
+		"Name": new_game.Name,
+		"Config": new_game.Config,
+	})
+}
+
+/**
+ * <core>.adminOnly:
+ * The function to wrap the handler so it only serves the request with the admin bearer token
+ *
+ * @param {func(*App, http.ResponseWriter, *http.Request)} handler							- the admin handler
+ *
+ * @return {func(*App, http.ResponseWriter, *http.Request)}
+ */
+func adminOnly(handler func(*App, http.ResponseWriter, *http.Request)) func(*App, http.ResponseWriter, *http.Request) {
+	return func(app *App, w http.ResponseWriter, r *http.Request) {
+		var token = app.Configuration.Admin.Token
+		// the admin api is disabled without a token
+		if (token == "") {
+			http.Error(w, "Admin api is disabled", 403)
+			return
+		}
+		var given = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if (subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1) {
+			http.Error(w, "Unauthorized", 401)
+			return
+		}
+		handler(app, w, r)
+	}
+}
+
+/**
+ * <core>.adminCloseRoomHandler:
+ * The function to handle the request to close a game room by force in url "/api/admin/rooms/{name}"
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func adminCloseRoomHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodDelete) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var room_name = strings.TrimPrefix(r.URL.Path, "/api/admin/rooms/")
+	if err := app.closeGame(room_name); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	log.Printf("Game room %s closed by admin", room_name)
+	writeJSON(w, 200, map[string]interface{} {
+		"Name": room_name,
+	})
+}
+
+/**
+ * <core>.adminKickHandler:
+ * The function to handle the request to kick a player from a game room
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func adminKickHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodPost) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var request kickRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body!", 400)
+		return
+	}
+	var select_game = app.findGame(request.Room)
+	if (select_game == nil) {
+		http.Error(w, "The game room " + request.Room + " does not exist!", 404)
+		return
+	}
+	if (!select_game.Kick(request.Name, request.Reason)) {
+		http.Error(w, "The player " + request.Name + " is not in the game room!", 404)
+		return
+	}
+	writeJSON(w, 200, map[string]interface{} {
+		"Room": request.Room,
+		"Name": request.Name,
+	})
+}
+
+/**
+ * <core>.adminBansHandler:
+ * The function to handle the request to list (GET), add (POST) or lift (DELETE with name or ip query) the bans
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func adminBansHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	switch (r.Method) {
+		case http.MethodGet:
+			writeJSON(w, 200, app.Bans.List())
+		case http.MethodPost:
+			var request banRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "Invalid request body!", 400)
+				return
+			}
+			var ban = Ban {
+				Name: request.Name,
+				IP: request.IP,
+				Reason: request.Reason,
+			}
+			if (request.Duration > 0) {
+				ban.Expire = time.Now().Add(time.Duration(request.Duration) * time.Second)
+			}
+			if err := app.Bans.Add(ban); err != nil {
+				http.Error(w, err.Error(), 400)
+				return
+			}
+			// kick the banned players out of all game rooms
+			var kicked = 0
+			for _, g := range app.games() {
+				if (ban.Name != "" && g.Kick(ban.Name, "banned: " + ban.Reason)) {
+					kicked += 1
+				}
+				if (ban.IP != "") {
+					kicked += g.KickIP(ban.IP, "banned: " + ban.Reason)
+				}
+			}
+			log.Printf("Ban added by admin on name %q ip %q, %d player(s) kicked", ban.Name, ban.IP, kicked)
+			writeJSON(w, 201, map[string]interface{} {
+				"Ban": ban,
+				"Kicked": kicked,
+			})
+		case http.MethodDelete:
+			var queries = r.URL.Query()
+			removed, err := app.Bans.Remove(queries.Get("name"), queries.Get("ip"))
+			if (err != nil) {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			writeJSON(w, 200, map[string]interface{} {
+				"Removed": removed,
+			})
+		default:
+			http.Error(w, "Method not allowed", 405)
+	}
+}
+
+/**
+ * <core>.adminBroadcastHandler:
+ * The function to handle the request to broadcast a server message to one or all game rooms
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func adminBroadcastHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodPost) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var request broadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body!", 400)
+		return
+	}
+	if (request.Message == "") {
+		http.Error(w, "Message can not be empty!", 400)
+		return
+	}
+	var games = app.games()
+	if (request.Room != "") {
+		var select_game = app.findGame(request.Room)
+		if (select_game == nil) {
+			http.Error(w, "The game room " + request.Room + " does not exist!", 404)
+			return
+		}
+		games = []*game.Game { select_game }
+	}
+	for _, g := range games {
+		g.Broadcast(request.Message)
+	}
+	writeJSON(w, 200, map[string]interface{} {
+		"Rooms": len(games),
+	})
+}
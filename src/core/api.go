@@ -0,0 +1,76 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/game"
+)
+
+/**
+ * <core>.roomsHandler:
+ * The function to handle the request to list all game rooms
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func roomsHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodGet) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var games = app.games()
+	var rooms = make([]game.RoomSummary, 0, len(games))
+	for _, g := range games {
+		rooms = append(rooms, g.Summary())
+	}
+	writeJSON(w, 200, rooms)
+}
+
+/**
+ * <core>.roomHandler:
+ * The function to handle the request to inspect one game room by the name in url "/api/rooms/{name}"
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func roomHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodGet) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var room_name = strings.TrimPrefix(r.URL.Path, "/api/rooms/")
+	var select_game = app.findGame(room_name)
+	if (select_game == nil) {
+		http.Error(w, "The game room " + room_name + " does not exist!", 404)
+		return
+	}
+	writeJSON(w, 200, map[string]interface{} {
+		"Room": select_game.Summary(),
+		"Config": select_game.Config,
+		"Leaderboard": select_game.Leaderboard(),
+	})
+}
+
+/**
+ * <core>.statusHandler:
+ * The function to handle the request to get the server status
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {nil}
+ */
+func statusHandler(app *App, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodGet) {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	writeJSON(w, 200, app.status())
+}
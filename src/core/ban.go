@@ -0,0 +1,193 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+/**
+ * Ban:
+ * The struct of one ban on a player name or an ip.
+ *
+ * @property {string} Name 																			- the banned player name, empty to match any name
+ * @property {string} IP																				- the banned ip, empty to match any ip
+ * @property {string} Reason																		- the reason of the ban
+ * @property {time.Time} Expire																	- the time when the ban expires, zero if permanent
+ */
+type Ban struct {
+	Name string
+	IP string
+	Reason string
+	Expire time.Time
+}
+
+/**
+ * BanList:
+ * The struct to keep the bans and persist them to the ban file.
+ *
+ * @property {[]Ban} bans 																			- the slice of the bans
+ * @property {string} file																			- the path of the ban file
+ */
+type BanList struct {
+	bans []Ban
+	file string
+	ControlLock sync.Mutex
+}
+
+/**
+ * <Ban>.expired:
+ * The function in Ban to check if the ban has expired.
+ *
+ * @return {bool}
+ */
+func (b Ban) expired() bool {
+	return !b.Expire.IsZero() && time.Now().After(b.Expire)
+}
+
+/**
+ * <Ban>.match:
+ * The function in Ban to check if the ban matches the player name or the ip.
+ *
+ * @param {string} name																					- the player name
+ * @param {string} ip																						- the ip of the client
+ *
+ * @return {bool}
+ */
+func (b Ban) match(name, ip string) bool {
+	return (b.Name != "" && b.Name == name) || (b.IP != "" && b.IP == ip)
+}
+
+/**
+ * <core>.loadBanList:
+ * The function to load the ban list from the ban file, the list is empty if the file does not exist.
+ *
+ * @param {string} file																					- the path of the ban file
+ *
+ * @return {*BanList, error}
+ */
+func loadBanList(file string) (*BanList, error) {
+	var list = &BanList {
+		bans: []Ban {},
+		file: file,
+	}
+	jsonFile, err := os.Open(file)
+	if (os.IsNotExist(err)) {
+		return list, nil
+	} else if (err != nil) {
+		return nil, err
+	}
+	defer jsonFile.Close()
+	byteValue, _ := ioutil.ReadAll(jsonFile)
+	if err := json.Unmarshal(byteValue, &list.bans); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+/**
+ * <*BanList>.save:
+ * The function in BanList to drop the expired bans and write the others to the ban file.
+ * The caller should hold the lock.
+ *
+ * @return {error}
+ */
+func (l *BanList) save() error {
+	var bans = l.bans[:0]
+	for _, ban := range l.bans {
+		if (!ban.expired()) {
+			bans = append(bans, ban)
+		}
+	}
+	l.bans = bans
+	byteValue, err := json.MarshalIndent(l.bans, "", "  ")
+	if (err != nil) {
+		return err
+	}
+	return ioutil.WriteFile(l.file, byteValue, 0644)
+}
+
+/**
+ * <*BanList>.Add:
+ * The function in BanList to add the ban and persist it.
+ *
+ * @param {Ban} ban																							- the new ban
+ *
+ * @return {error}
+ */
+func (l *BanList) Add(ban Ban) error {
+	if (ban.Name == "" && ban.IP == "") {
+		return errors.New("Ban name and ip can not be both empty!")
+	}
+	l.ControlLock.Lock()
+	defer l.ControlLock.Unlock()
+	l.bans = append(l.bans, ban)
+	return l.save()
+}
+
+/**
+ * <*BanList>.Remove:
+ * The function in BanList to lift all bans on the player name or the ip and persist the change.
+ *
+ * @param {string} name																					- the player name
+ * @param {string} ip																						- the ip of the client
+ *
+ * @return {int, error}																					- the number of the lifted bans
+ */
+func (l *BanList) Remove(name, ip string) (int, error) {
+	l.ControlLock.Lock()
+	defer l.ControlLock.Unlock()
+	var bans = l.bans[:0]
+	var removed = 0
+	for _, ban := range l.bans {
+		if (ban.match(name, ip)) {
+			removed += 1
+		} else {
+			bans = append(bans, ban)
+		}
+	}
+	l.bans = bans
+	return removed, l.save()
+}
+
+/**
+ * <*BanList>.Find:
+ * The function in BanList to find an active ban on the player name or the ip.
+ *
+ * @param {string} name																					- the player name
+ * @param {string} ip																						- the ip of the client
+ *
+ * @return {*Ban}																								- nil if not banned
+ */
+func (l *BanList) Find(name, ip string) *Ban {
+	l.ControlLock.Lock()
+	defer l.ControlLock.Unlock()
+	for _, ban := range l.bans {
+		if (!ban.expired() && ban.match(name, ip)) {
+			var found = ban
+			return &found
+		}
+	}
+	return nil
+}
+
+/**
+ * <*BanList>.List:
+ * The function in BanList to get all active bans.
+ *
+ * @return {[]Ban}
+ */
+func (l *BanList) List() []Ban {
+	l.ControlLock.Lock()
+	defer l.ControlLock.Unlock()
+	var bans = []Ban {}
+	for _, ban := range l.bans {
+		if (!ban.expired()) {
+			bans = append(bans, ban)
+		}
+	}
+	return bans
+}
@@ -5,6 +5,13 @@ import (
 	"os"
 )
 
+// define the default seconds to keep an empty room
+const defaultRoomIdleTimeout = 60
+// define the default max seconds of the shutdown
+const defaultShutdownDeadline = 10
+// define the default path of the ban file
+const defaultBanFile = "src/config/bans.json"
+
 /**
  * ServerConfiguration:
  * The struct to present the server configuration.
@@ -12,23 +19,43 @@ import (
  * @property {string} Host 								- the host string of the server
  * @property {string} Port								- the port string of the server
  * @property {int} MaxRoom								- the max number of the room
+ * @property {int} MaxRoomMember				- the upper bound of the max players in every room, 0 for no bound
+ * @property {int} RoomIdleTimeout				- the seconds an empty room is kept before it is closed
+ * @property {int} ShutdownCountdown			- the seconds to warn the players before the server shuts down
+ * @property {int} ShutdownDeadline				- the max seconds of the whole shutdown
  */
 type ServerConfiguration struct {
 	Host string
 	Port string
 	MaxRoom int
 	MaxRoomMember int
+	RoomIdleTimeout int
+	ShutdownCountdown int
+	ShutdownDeadline int
 }
 
+/**
+ * AdminConfiguration:
+ * The struct to present the admin api configuration.
+ *
+ * @property {string} Token 							- the bearer token of the admin api, the api is disabled if empty
+ * @property {string} BanFile							- the path of the file to persist the bans
+ */
+type AdminConfiguration struct {
+	Token string
+	BanFile string
+}
 
 /**
  * Configuration:
  * The struct to present all configuration of the project.
  *
  * @property {ServerConfiguration} Server - the configuration of the server
+ * @property {AdminConfiguration} Admin		- the configuration of the admin api
  */
 type Configuration struct {
 	Server ServerConfiguration
+	Admin AdminConfiguration
 }
 
 
@@ -46,5 +73,17 @@ func (c *Configuration) loadFromFile() error {
 		return err
 	}
 	c.Server.MaxRoom = 50
+	if (c.Server.RoomIdleTimeout <= 0) {
+		c.Server.RoomIdleTimeout = defaultRoomIdleTimeout
+	}
+	if (c.Server.ShutdownCountdown < 0) {
+		c.Server.ShutdownCountdown = 0
+	}
+	if (c.Server.ShutdownDeadline <= 0) {
+		c.Server.ShutdownDeadline = defaultShutdownDeadline
+	}
+	if (c.Admin.BanFile == "") {
+		c.Admin.BanFile = defaultBanFile
+	}
 	return nil
 }
@@ -1,15 +1,32 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"github.com/f26401004/Lifegamer-Diep-backend/src/game"
 	"sync"
+	"time"
 )
 
+// define the interval to look for the idle rooms
+const roomReapInterval = 5 * time.Second
+
+/**
+ * ServerStatus:
+ * The struct to present the current status of the server.
+ *
+ * @property {int} RoomNumber 															- the number of the opened game rooms
+ * @property {int} PlayerNumber															- the number of the players in all game rooms
+ * @property {int} RequestNumber														- the number of the join requests since the server started
+ * @property {float64} Uptime																- the seconds since the server started
+ */
 type ServerStatus struct {
+	RoomNumber int
 	PlayerNumber int
 	RequestNumber int
+	Uptime float64
 }
 
 /**
@@ -18,13 +35,21 @@ type ServerStatus struct {
  *
  * @property {*Configuration} Configuration 								- the configuration struct of the app
  * @property {[]*game.Game} Games														- the slice of the games of the app
- * @property {chan *game.Game} CreateChannel								- the channel of create game
+ * @property {*BanList} Bans																- the bans on the player names and ips
+ * @property {int} requestNumber														- the number of the join requests since the server started
+ * @property {time.Time} startedAt													- the time when the server started
+ * @property {bool} shuttingDown														- whether the server is shutting down and rejects new players
+ * @property {*http.Server} server													- the http server of the app
  */
 type App struct {
 	Configuration *Configuration
-	Status ServerStatus
 	Games []*game.Game
+	Bans *BanList
 	ControlLock sync.Mutex
+	requestNumber int
+	startedAt time.Time
+	shuttingDown bool
+	server *http.Server
 }
 
 /**
@@ -35,13 +60,191 @@ type App struct {
  */
 func (app *App) Run() {
 	fmt.Println("core run...")
+	app.startedAt = time.Now()
 	app.Configuration = &Configuration{}
 	err := app.Configuration.loadFromFile()
 	if err != nil {
 		log.Fatal("Error loading config:", err)
 	}
+	app.Bans, err = loadBanList(app.Configuration.Admin.BanFile)
+	if err != nil {
+		log.Fatal("Error loading ban list:", err)
+	}
+	config, err := game.GetRoomPreset(game.DefaultRoomPreset)
+	if err != nil {
+		log.Fatal("Error loading room preset:", err)
+	}
+	config = app.limitMembers(config)
+	playground := game.NewGame(game.DefaultRoomPreset, config)
+	// the default room is never closed even if it is empty
+	playground.Persistent = true
 	app.ControlLock.Lock()
-	app.Games = append(app.Games, game.NewGame("playground", 8192, 8192) )
+	app.Games = append(app.Games, playground)
 	app.ControlLock.Unlock()
+	go app.reapRooms()
 	app.runServer()
 }
+
+/**
+ * <*App>.findGame:
+ * The function in App to find the game room by name.
+ *
+ * @param {string} name																						- the name of the game room
+ *
+ * @return {*game.Game}
+ */
+func (app *App) findGame(name string) *game.Game {
+	app.ControlLock.Lock()
+	defer app.ControlLock.Unlock()
+	for _, g := range app.Games {
+		if (g.Name == name) {
+			return g
+		}
+	}
+	return nil
+}
+
+/**
+ * <*App>.createGame:
+ * The function in App to create a new game room with the config.
+ *
+ * @param {string} name																						- the name of the game room
+ * @param {game.RoomConfig} config																- the settings of the game room
+ *
+ * @return {*game.Game, error}
+ */
+func (app *App) createGame(name string, config game.RoomConfig) (*game.Game, error) {
+	config = app.limitMembers(config)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	app.ControlLock.Lock()
+	defer app.ControlLock.Unlock()
+	if (app.shuttingDown) {
+		return nil, errors.New("Server is shutting down!")
+	}
+	for _, g := range app.Games {
+		if (g.Name == name) {
+			return nil, errors.New("The game room " + name + " already exists!")
+		}
+	}
+	// if the room number meet the maximum
+	if (len(app.Games) >= app.Configuration.Server.MaxRoom) {
+		return nil, errors.New("Server room number meet the maximum!")
+	}
+	new_game := game.NewGame(name, config)
+	app.Games = append(app.Games, new_game)
+	return new_game, nil
+}
+
+/**
+ * <*App>.limitMembers:
+ * The function in App to cap the max players of the room config at the server-wide max room member.
+ *
+ * @param {game.RoomConfig} config																- the settings of the game room
+ *
+ * @return {game.RoomConfig}
+ */
+func (app *App) limitMembers(config game.RoomConfig) game.RoomConfig {
+	var limit = app.Configuration.Server.MaxRoomMember
+	if (limit > 0 && config.MaxMembers > limit) {
+		log.Printf("Room max members %d capped at the server max room member %d", config.MaxMembers, limit)
+		config.MaxMembers = limit
+	}
+	return config
+}
+
+/**
+ * <*App>.reapRooms:
+ * The function in App to keep closing the rooms which stay empty over the idle timeout.
+ * The closed rooms are removed from the app first, so no player can join them afterward.
+ *
+ * @return {nil}
+ */
+func (app *App) reapRooms() {
+	var timeout = time.Duration(app.Configuration.Server.RoomIdleTimeout) * time.Second
+	for {
+		time.Sleep(roomReapInterval)
+		var idle_games = []*game.Game {}
+		app.ControlLock.Lock()
+		var games = app.Games[:0]
+		for _, g := range app.Games {
+			if (!g.Persistent && g.IdleFor() >= timeout) {
+				idle_games = append(idle_games, g)
+			} else {
+				games = append(games, g)
+			}
+		}
+		app.Games = games
+		app.ControlLock.Unlock()
+		// stop the routines of the idle rooms outside the app lock
+		for _, g := range idle_games {
+			g.Close()
+			log.Printf("Game room %s closed after idle for %s", g.Name, timeout)
+		}
+	}
+}
+
+/**
+ * <*App>.status:
+ * The function in App to compute the current server status from the game rooms.
+ *
+ * @return {ServerStatus}
+ */
+func (app *App) status() ServerStatus {
+	var games = app.games()
+	app.ControlLock.Lock()
+	var status = ServerStatus {
+		RoomNumber: len(games),
+		RequestNumber: app.requestNumber,
+		Uptime: time.Since(app.startedAt).Seconds(),
+	}
+	app.ControlLock.Unlock()
+	// count the members outside the app lock
+	for _, g := range games {
+		status.PlayerNumber += g.Summary().Members
+	}
+	return status
+}
+
+/**
+ * <*App>.closeGame:
+ * The function in App to remove the game room by name and close it by force.
+ *
+ * @param {string} name																						- the name of the game room
+ *
+ * @return {error}
+ */
+func (app *App) closeGame(name string) error {
+	var target *game.Game = nil
+	app.ControlLock.Lock()
+	for index, g := range app.Games {
+		if (g.Name == name) {
+			if (g.Persistent) {
+				app.ControlLock.Unlock()
+				return errors.New("The game room " + name + " can not be closed!")
+			}
+			target = g
+			app.Games = append(app.Games[:index], app.Games[index+1:]...)
+			break
+		}
+	}
+	app.ControlLock.Unlock()
+	if (target == nil) {
+		return errors.New("The game room " + name + " does not exist!")
+	}
+	target.Close()
+	return nil
+}
+
+/**
+ * <*App>.games:
+ * The function in App to get a copy of the game rooms, so the caller can visit them without the app lock.
+ *
+ * @return {[]*game.Game}
+ */
+func (app *App) games() []*game.Game {
+	app.ControlLock.Lock()
+	defer app.ControlLock.Unlock()
+	return append([]*game.Game {}, app.Games...)
+}
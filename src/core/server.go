@@ -1,11 +1,12 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"log"
+	"net"
 	"net/http"
-	"net/url"
 	"net/http/pprof"
 	"github.com/f26401004/Lifegamer-Diep-backend/src/game"
 )
@@ -47,83 +48,182 @@ func (sh serverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
  * @return {nil}
  */
 func gameWebsocketHandler(app *App, w http.ResponseWriter, r *http.Request) {
-	// get the websocket instance
-	ws, err := websocket.Upgrade(w, r, nil, 1024, 1024)
-	// return error message if the websocket handshake not established 
-	if _, ok := err.(websocket.HandshakeError); ok {
-		http.Error(w, "Not a websocket handshake", 400)
+	// get the query
+	queries := r.URL.Query()
+	var player_name = queries.Get("name")
+	var room_name = queries.Get("room")
+	// reject the new player if the server is shutting down
+	if (app.isShuttingDown()) {
+		http.Error(w, "Server is shutting down!", 503)
 		return
-	// just return if other error happend
-	} else if err != nil {
+	}
+	// negotiate the wire protocol, json by default
+	codec, err := game.NewCodec(queries.Get("protocol"))
+	if (err != nil) {
+		http.Error(w, err.Error(), 400)
 		return
 	}
-
-	// get the query
-	queries, _ := url.ParseQuery(r.URL.RawQuery)
-	if (queries["name"][0] == "") {
+	// reattach to the suspended player if the client brings the resume token
+	if (queries.Get("resume") != "") {
+		resumeWebsocketHandler(app, w, r, room_name, queries.Get("resume"), codec)
+		return
+	}
+	if (player_name == "") {
 		log.Println("[Error]: Player name can not be empty!")
 		http.Error(w, "Player name can not be empty!", 400)
 		return
 	}
-	var select_game *game.Game = nil
-	// if the room number meet the maximum
-	if (len(app.Games) >= (*app.Configuration).Server.MaxRoom) {
-		log.Println("[Error]: The Room number meet the maximum!")
-		http.Error(w, "Server room number meet the maximum!", 400)
+	// reject the banned player name or ip
+	if ban := app.Bans.Find(player_name, clientIP(r)); ban != nil {
+		log.Printf("[Error]: Banned player %s from %s", player_name, clientIP(r))
+		http.Error(w, "You are banned: " + ban.Reason, 403)
 		return
 	}
+	var select_game *game.Game = nil
 	// search the game room by room name
-	if (queries["room"][0] != "") {
-		for _, game := range app.Games {
-			if (game.Name == queries["room"][0]) {
-				select_game = game	
-			}
-		}
-		if (len(app.Games) > app.Configuration.Server.MaxRoom) {
-			log.Println("[Error]: The number of game room meet maximum!")
-			http.Error(w, "The number of game room meet maximum !", 400)
-			return
-		}
-		// if the game room do not exist, then create new game room
+	if (room_name != "") {
+		select_game = app.findGame(room_name)
+		// if the game room do not exist, then create new game room with the preset
 		if (select_game == nil) {
-			select_game = game.NewGame(queries["room"][0], 8192.0, 8192.0)
-			// app.ControlLock.Lock()
-			app.Games = append(app.Games, select_game)
-			// app.ControlLock.Unlock()
+			config, err := game.GetRoomPreset(queries.Get("preset"))
+			if (err != nil) {
+				log.Println("[Error]:", err)
+				http.Error(w, err.Error(), 400)
+				return
+			}
+			select_game, err = app.createGame(room_name, config)
+			if (err != nil) {
+				log.Println("[Error]:", err)
+				http.Error(w, err.Error(), 400)
+				return
+			}
 		}
  	} else {
 		// default select the first game instance
-		select_game = app.Games[0]
+		select_game = app.findGame(game.DefaultRoomPreset)
+		if (select_game == nil) {
+			log.Println("[Error]: The default game room is not available!")
+			http.Error(w, "The default game room is not available!", 503)
+			return
+		}
 	}
-	// check if the room member do no meet the maximum
-	if (len(select_game.Sessions) >= (*app.Configuration).Server.MaxRoomMember) {
-		log.Println("[Error]: The member of the game rom meet maximum!")
-		http.Error(w, "The member of game room meet maximum", 400)
+	// check the room member number and the repeatation of the player name
+	if err := select_game.CheckJoin(player_name); err != nil {
+		log.Println("[Error]:", err)
+		http.Error(w, err.Error(), 400)
 		return
 	}
-	// check repeatation of the player name
-	// select_game.ControlLock.Lock()
-	for _, ps := range select_game.Sessions {
-		if (ps.Player.Attr.Name == queries["name"][0]) {
-			log.Println("[Error]: Repeat player name!")
-			http.Error(w, "Repeat player name!", 400)
-			return
-		}
+
+	// get the websocket instance
+	ws, err := websocket.Upgrade(w, r, nil, 1024, 1024)
+	// return error message if the websocket handshake not established 
+	if _, ok := err.(websocket.HandshakeError); ok {
+		http.Error(w, "Not a websocket handshake", 400)
+		return
+	// just return if other error happend
+	} else if err != nil {
+		return
 	}
-	// select_game.ControlLock.Unlock()
 	// generate the player instance
-	player := game.NewPlayer(queries["name"][0])
+	player := game.NewPlayer(player_name)
 	// generate the player session
 	session := game.NewSession(ws, player, select_game)
+	session.Codec = codec
 	// add the player session to the game
-	select_game.JoinPlayer(session)
+	if err := select_game.JoinPlayer(session); err != nil {
+		log.Println("[Error]:", err)
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()))
+		ws.Close()
+		return
+	}
 
 	// record some server information here
-	(*app).Status.PlayerNumber += 1
-	(*app).Status.RequestNumber += 1
+	app.ControlLock.Lock()
+	app.requestNumber += 1
+	app.ControlLock.Unlock()
+
+	var status = app.status()
+	log.Printf("Player %s joined to game room %s", player_name, select_game.Name)
+	log.Printf("Server Status: %d room(s), %d player(s)", status.RoomNumber, status.PlayerNumber)
+}
+
+/**
+ * <core>.resumeWebsocketHandler:
+ * The function to handle the request upgrade to websocket with a resume token from client
+ *
+ * @param {*App} app 																														- the app reference
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {*http.Request} r																											- the current request
+ * @param {string} room_name																										- the name of the game room, the default room if empty
+ * @param {string} token																												- the resume token
+ * @param {game.Codec} codec																										- the codec negotiated by the client
+ *
+ * @return {nil}
+ */
+func resumeWebsocketHandler(app *App, w http.ResponseWriter, r *http.Request, room_name string, token string, codec game.Codec) {
+	if (room_name == "") {
+		room_name = game.DefaultRoomPreset
+	}
+	var select_game = app.findGame(room_name)
+	if (select_game == nil) {
+		http.Error(w, "The game room " + room_name + " does not exist!", 404)
+		return
+	}
+	var player = select_game.SuspendedPlayer(token)
+	if (player == nil) {
+		http.Error(w, "The resume token is invalid or expired!", 404)
+		return
+	}
+	if ban := app.Bans.Find(player.Attr.Name, clientIP(r)); ban != nil {
+		http.Error(w, "You are banned: " + ban.Reason, 403)
+		return
+	}
+	ws, err := websocket.Upgrade(w, r, nil, 1024, 1024)
+	if _, ok := err.(websocket.HandshakeError); ok {
+		http.Error(w, "Not a websocket handshake", 400)
+		return
+	} else if err != nil {
+		return
+	}
+	if _, err := select_game.Resume(token, ws, codec); err != nil {
+		log.Println("[Error]:", err)
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()))
+		ws.Close()
+		return
+	}
+	log.Printf("Player %s resumed to game room %s", player.Attr.Name, select_game.Name)
+}
+
+/**
+ * <core>.writeJSON:
+ * The function to write the value to client in json format
+ *
+ * @param {http.ResponseWriter} w																								- the response writer of current request
+ * @param {int} status																													- the http status code
+ * @param {interface{}} value																										- the value to encode
+ *
+ * @return {nil}
+ */
+func writeJSON (w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
 
-	log.Printf("Player %s joined to game room %s", queries["name"][0], queries["room"][0])
-	log.Printf("Server Status: %d room(s), %d player(s)", len((*app).Games), 1)
+/**
+ * <core>.clientIP:
+ * The function to get the ip of the client from the request
+ *
+ * @param {*http.Request} r																											- the current request
+ *
+ * @return {string}
+ */
+func clientIP (r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if (err != nil) {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 /**
@@ -140,16 +240,26 @@ func staticHandler (w http.ResponseWriter, r *http.Request) {
 }
 
 /**
- * <App>.runServer:
+ * <*App>.runServer:
  * The function in App to run server
  *
  * @return {nil}
  */
-func (app App) runServer () {
+func (app *App) runServer () {
 	// handle the static file in url "/"
 	http.HandleFunc("/", staticHandler)
 	// handle the game websocket messaging in url "/game_ws"
-	http.Handle("/game_ws", serverHandler { &app, gameWebsocketHandler })
+	http.Handle("/game_ws", serverHandler { app, gameWebsocketHandler })
+	// handle the admin api in url "/api/admin"
+	http.Handle("/api/admin/rooms", serverHandler { app, adminOnly(adminCreateRoomHandler) })
+	http.Handle("/api/admin/rooms/", serverHandler { app, adminOnly(adminCloseRoomHandler) })
+	http.Handle("/api/admin/kick", serverHandler { app, adminOnly(adminKickHandler) })
+	http.Handle("/api/admin/bans", serverHandler { app, adminOnly(adminBansHandler) })
+	http.Handle("/api/admin/broadcast", serverHandler { app, adminOnly(adminBroadcastHandler) })
+	// handle the public api in url "/api"
+	http.Handle("/api/rooms", serverHandler { app, roomsHandler })
+	http.Handle("/api/rooms/", serverHandler { app, roomHandler })
+	http.Handle("/api/status", serverHandler { app, statusHandler })
 
 	go func () {
 		r := http.NewServeMux()
@@ -161,8 +271,17 @@ func (app App) runServer () {
 		http.ListenAndServe(":3000", r)
 	}()
 
+	app.server = &http.Server {
+		Addr: fmt.Sprintf("%s:%s", (*app.Configuration).Server.Host, (*app.Configuration).Server.Port),
+	}
+	// shut down the server gracefully on SIGINT or SIGTERM
+	var done = make(chan bool)
+	go app.waitForSignal(done)
+
 	log.Println("run server on port", (*app.Configuration).Server.Port)
-	if err := http.ListenAndServe(fmt.Sprintf("%s:%s", (*app.Configuration).Server.Host, (*app.Configuration).Server.Port), nil); err != nil {
+	if err := app.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal("ListenAndServe: ", err)
 	}
+	// wait for the rooms to be drained
+	<- done
 }
\ No newline at end of file
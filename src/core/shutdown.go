@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/game"
+)
+
+/**
+ * <*App>.waitForSignal:
+ * The function in App to block until SIGINT or SIGTERM, then shut down the server.
+ *
+ * @param {chan bool} done																				- the channel closed when the shutdown finishes
+ *
+ * @return {nil}
+ */
+func (app *App) waitForSignal(done chan bool) {
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <- signals
+	log.Printf("Receive signal %s, shutting down...", sig)
+	// exit at once on the second signal
+	go func () {
+		<- signals
+		log.Fatal("Force shutdown")
+	}()
+	app.shutdown()
+	close(done)
+}
+
+/**
+ * <*App>.isShuttingDown:
+ * The function in App to check if the server is shutting down.
+ *
+ * @return {bool}
+ */
+func (app *App) isShuttingDown() bool {
+	app.ControlLock.Lock()
+	defer app.ControlLock.Unlock()
+	return app.shuttingDown
+}
+
+/**
+ * <*App>.shutdown:
+ * The function in App to stop accepting new players, count down in every room,
+ * then close all rooms and the http server within the shutdown deadline.
+ *
+ * @return {nil}
+ */
+func (app *App) shutdown() {
+	var deadline = time.Now().Add(time.Duration(app.Configuration.Server.ShutdownDeadline) * time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	// reject the new websocket upgrades and room creations
+	app.ControlLock.Lock()
+	app.shuttingDown = true
+	app.ControlLock.Unlock()
+
+	// warn the players every second until the countdown ends
+	for countdown := app.Configuration.Server.ShutdownCountdown; countdown > 0; countdown-- {
+		for _, g := range app.games() {
+			g.NotifyShutdown(countdown)
+		}
+		select {
+			case <- time.After(time.Second):
+			case <- ctx.Done():
+		}
+		if (ctx.Err() != nil) {
+			break
+		}
+	}
+
+	// close all rooms, including the default one
+	app.ControlLock.Lock()
+	var games = app.Games
+	app.Games = []*game.Game {}
+	app.ControlLock.Unlock()
+	var closed = make(chan bool)
+	go func () {
+		var wait sync.WaitGroup
+		for _, g := range games {
+			wait.Add(1)
+			go func (g *game.Game) {
+				defer wait.Done()
+				g.Close()
+			}(g)
+		}
+		wait.Wait()
+		close(closed)
+	}()
+	select {
+		case <- closed:
+			log.Printf("%d game room(s) closed", len(games))
+		case <- ctx.Done():
+			log.Println("[Error]: Shutdown deadline exceeded before all rooms closed")
+	}
+
+	if err := app.server.Shutdown(ctx); err != nil {
+		log.Println("[Error]: Shutdown http server:", err)
+	}
+}
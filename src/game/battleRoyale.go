@@ -0,0 +1,408 @@
+package game
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the states of the battle royale round
+const (
+	royaleLobby = iota
+	royaleRunning
+	royaleFinished
+)
+
+/**
+ * ZonePhase:
+ * The struct of one phase of the safe zone in the battle royale mode.
+ *
+ * @property {float64} Wait																		- the seconds the zone holds before shrinking
+ * @property {float64} Shrink																	- the seconds the zone takes to shrink
+ * @property {float64} Radius																	- the radius of the zone after shrinking
+ * @property {float64} Damage																	- the HP per second the zone takes from the dieps outside
+ */
+type ZonePhase struct {
+	Wait float64
+	Shrink float64
+	Radius float64
+	Damage float64
+}
+
+/**
+ * BattleRoyale:
+ * The last-tank-standing mode. The round starts after the lobby countdown, the safe zone shrinks
+ * in phases and the dead players watch until the room resets for the next round.
+ *
+ * @property {int} state																			- the state of the round
+ * @property {int} minPlayers																	- the number of the players to start the lobby countdown
+ * @property {float64} lobbySeconds														- the seconds of the lobby countdown
+ * @property {[]ZonePhase} phases															- the phases of the safe zone
+ * @property {int} countdown																	- the remaining ticks of the lobby countdown
+ * @property {int} phase																			- the index of the current zone phase
+ * @property {int} phaseTicks																	- the ticks since the current phase started
+ * @property {util.Vec2} center																- the center of the safe zone
+ * @property {float64} radius																	- the radius of the safe zone
+ * @property {util.Vec2} fromCenter														- the center of the safe zone when the phase started
+ * @property {float64} fromRadius															- the radius of the safe zone when the phase started
+ * @property {util.Vec2} nextCenter														- the center of the safe zone after the phase
+ */
+type BattleRoyale struct {
+	state int
+	minPlayers int
+	lobbySeconds float64
+	phases []ZonePhase
+	countdown int
+	phase int
+	phaseTicks int
+	center util.Vec2
+	radius float64
+	fromCenter util.Vec2
+	fromRadius float64
+	nextCenter util.Vec2
+}
+
+/**
+ * <game>.NewBattleRoyale:
+ * The function to new the battle royale mode.
+ *
+ * @param {RoomConfig} config																	- the room config
+ *
+ * @return {*BattleRoyale}
+ */
+func NewBattleRoyale (config RoomConfig) *BattleRoyale {
+	return &BattleRoyale {
+		state: royaleLobby,
+		minPlayers: config.MinPlayers,
+		lobbySeconds: config.LobbySeconds,
+		phases: config.ZonePhases,
+		countdown: int(math.Ceil(config.LobbySeconds * config.TickRate)),
+	}
+}
+
+/**
+ * <*BattleRoyale>.Name:
+ * The function in BattleRoyale to get the mode name.
+ *
+ * @return {string}
+ */
+func (m *BattleRoyale) Name () string {
+	return RoyaleMode
+}
+
+/**
+ * <*BattleRoyale>.CanJoin:
+ * The function in BattleRoyale to check if a new player can join, only in the lobby.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *BattleRoyale) CanJoin (g *Game) bool {
+	return m.state == royaleLobby
+}
+
+/**
+ * <*BattleRoyale>.OnJoin:
+ * The function in BattleRoyale to prepare the joining player, there is nothing to do.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the joining player
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) OnJoin (g *Game, player *Player) {}
+
+/**
+ * <*BattleRoyale>.SpawnPoint:
+ * The function in BattleRoyale to get a random spawn point over the field.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the spawning player
+ *
+ * @return {util.Vec2}
+ */
+func (m *BattleRoyale) SpawnPoint (g *Game, player *Player) util.Vec2 {
+	return util.Vec2 {
+		X: rand.Float64() * g.Field.W,
+		Y: rand.Float64() * g.Field.H,
+	}
+}
+
+/**
+ * <*BattleRoyale>.aliveCount:
+ * The function in BattleRoyale to get the number of the players still in the fight, the players waiting for resuming are counted.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {int}
+ */
+func (m *BattleRoyale) aliveCount (g *Game) int {
+	var count = 0
+	for _, suspended := range g.suspended {
+		if (!suspended.session.Dead) {
+			count++
+		}
+	}
+	for _, ps := range g.Sessions {
+		if (ps.Playing()) {
+			count++
+		}
+	}
+	return count
+}
+
+/**
+ * <*BattleRoyale>.OnTick:
+ * The function in BattleRoyale to count down the lobby, shrink the safe zone and damage the dieps outside.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) OnTick (g *Game) {
+	switch (m.state) {
+		case royaleLobby:
+			// restart the countdown whenever the players are not enough
+			if (m.aliveCount(g) < m.minPlayers) {
+				m.countdown = int(math.Ceil(m.lobbySeconds * g.Framerate))
+				return
+			}
+			if (m.countdown > 0) {
+				m.countdown--
+				return
+			}
+			m.start(g)
+		case royaleRunning:
+			m.updateZone(g)
+			var phase = m.phases[int(math.Min(float64(m.phase), float64(len(m.phases) - 1)))]
+			for _, ps := range g.Sessions {
+				if (!ps.Playing()) || (ps.Player.Position.Sub(m.center).Length() <= m.radius) {
+					continue
+				}
+				ps.Player.Attr.HP -= phase.Damage / g.Framerate
+				if (ps.Player.Attr.HP <= 0) {
+					g.killPlayer(ps, "", "zone")
+				}
+			}
+			if (m.aliveCount(g) <= 1) {
+				m.state = royaleFinished
+			}
+	}
+}
+
+/**
+ * <*BattleRoyale>.start:
+ * The function in BattleRoyale to start the round with the safe zone covering the whole field.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) start (g *Game) {
+	m.state = royaleRunning
+	m.center = util.Vec2 { X: g.Field.W / 2, Y: g.Field.H / 2 }
+	m.radius = util.Vec2 { X: g.Field.W, Y: g.Field.H }.Length() / 2
+	m.enterPhase(g, 0)
+	log.Printf("Battle royale round of game room %s started with %d players", g.Name, m.aliveCount(g))
+}
+
+/**
+ * <*BattleRoyale>.enterPhase:
+ * The function in BattleRoyale to start the zone phase and pick the next safe zone inside the current one.
+ * The next center is kept on the field.
+ *
+ * @param {*Game} g																						- the game
+ * @param {int} phase																					- the index of the phase
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) enterPhase (g *Game, phase int) {
+	m.phase = phase
+	m.phaseTicks = 0
+	m.fromCenter = m.center
+	m.fromRadius = m.radius
+	if (phase >= len(m.phases)) {
+		m.nextCenter = m.center
+		return
+	}
+	var room = math.Max(m.radius - m.phases[phase].Radius, 0)
+	var angle = rand.Float64() * 2 * math.Pi
+	m.nextCenter = m.center.Add(util.Vec2 { X: math.Cos(angle), Y: math.Sin(angle) }.Scale(rand.Float64() * room)).Clamp(util.Vec2 {}, util.Vec2 { X: g.Field.W, Y: g.Field.H })
+}
+
+/**
+ * <*BattleRoyale>.updateZone:
+ * The function in BattleRoyale to move the safe zone toward the next one in the shrinking time of the phase.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) updateZone (g *Game) {
+	// the zone stays after the last phase
+	if (m.phase >= len(m.phases)) {
+		return
+	}
+	var phase = m.phases[m.phase]
+	m.phaseTicks++
+	var progress = (float64(m.phaseTicks) / g.Framerate - phase.Wait) / phase.Shrink
+	if (phase.Shrink <= 0) && (float64(m.phaseTicks) / g.Framerate >= phase.Wait) {
+		progress = 1
+	}
+	if (progress <= 0) {
+		return
+	}
+	progress = math.Min(progress, 1)
+	m.center = m.fromCenter.Add(m.nextCenter.Sub(m.fromCenter).Scale(progress))
+	m.radius = m.fromRadius + (phase.Radius - m.fromRadius) * progress
+	if (progress >= 1) {
+		m.enterPhase(g, m.phase + 1)
+	}
+}
+
+/**
+ * <*BattleRoyale>.RespawnLevel:
+ * The function in BattleRoyale to refuse the respawn, the dead players wait for the next round.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the dead player
+ *
+ * @return {int, bool}
+ */
+func (m *BattleRoyale) RespawnLevel (g *Game, player *Player) (int, bool) {
+	return 0, false
+}
+
+/**
+ * <*BattleRoyale>.OnDamage:
+ * The function in BattleRoyale to get the damage to apply, nothing hurts in the lobby.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the player to be damaged
+ * @param {int} attacker_team																	- the team of the attacker, 0 for no team
+ * @param {float64} damage																		- the damage by the collision
+ *
+ * @return {float64}
+ */
+func (m *BattleRoyale) OnDamage (g *Game, victim *Player, attacker_team int, damage float64) float64 {
+	if (m.state != royaleRunning) {
+		return 0
+	}
+	return damage
+}
+
+/**
+ * <*BattleRoyale>.IsEnemy:
+ * The function in BattleRoyale to check if the other player is an opponent, everyone is also in the lobby.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the player
+ * @param {*Player} other																			- the other player
+ *
+ * @return {bool}
+ */
+func (m *BattleRoyale) IsEnemy (g *Game, player, other *Player) bool {
+	return true
+}
+
+/**
+ * <*BattleRoyale>.OnKill:
+ * The function in BattleRoyale to count the kill, the round end is checked in the tick.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the dead player
+ * @param {*Player} killer																		- the killer, nil if killed by the others
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) OnKill (g *Game, victim, killer *Player) {}
+
+/**
+ * <*BattleRoyale>.OnLeave:
+ * The function in BattleRoyale to forget the leaving player, the players alive are counted in the tick.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the leaving player
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) OnLeave (g *Game, player *Player) {}
+
+/**
+ * <*BattleRoyale>.IsFinished:
+ * The function in BattleRoyale to check if only one player is left in the round.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *BattleRoyale) IsFinished (g *Game) bool {
+	return m.state == royaleFinished
+}
+
+/**
+ * <*BattleRoyale>.Reset:
+ * The function in BattleRoyale to reset the room for the next round, everyone starts over with a new tank in the lobby, also the suspended players.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *BattleRoyale) Reset (g *Game) {
+	for _, ps := range g.Sessions {
+		if (ps.Playing()) {
+			log.Printf("Player %s won the battle royale round of game room %s", ps.Player.Attr.Name, g.Name)
+		}
+		g.respawnPlayer(ps, 1)
+	}
+	// the players waiting for resuming start over as well
+	for _, suspended := range g.suspended {
+		g.respawnPlayer(suspended.session, 1)
+	}
+	m.state = royaleLobby
+	m.countdown = int(math.Ceil(m.lobbySeconds * g.Framerate))
+}
+
+/**
+ * <*BattleRoyale>.TeamScores:
+ * The function in BattleRoyale to get the team scores, always empty.
+ *
+ * @return {[]int}
+ */
+func (m *BattleRoyale) TeamScores () []int {
+	return []int {}
+}
+
+/**
+ * <*BattleRoyale>.Zone:
+ * The function in BattleRoyale to get the safe zone for the snapshots.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {ZoneSnapshot}
+ */
+func (m *BattleRoyale) Zone (g *Game) ZoneSnapshot {
+	var zone = ZoneSnapshot {
+		Active: true,
+	}
+	switch (m.state) {
+		case royaleLobby:
+			zone.X, zone.Y = g.Field.W / 2, g.Field.H / 2
+			zone.Radius = util.Vec2 { X: g.Field.W, Y: g.Field.H }.Length() / 2
+			zone.NextX, zone.NextY, zone.NextRadius = zone.X, zone.Y, zone.Radius
+			if (m.aliveCount(g) >= m.minPlayers) {
+				zone.Countdown = float64(m.countdown) / g.Framerate
+			}
+		default:
+			zone.Phase = int(math.Min(float64(m.phase + 1), float64(len(m.phases))))
+			zone.X, zone.Y, zone.Radius = m.center.X, m.center.Y, m.radius
+			zone.NextX, zone.NextY, zone.NextRadius = m.nextCenter.X, m.nextCenter.Y, m.radius
+			if (m.phase < len(m.phases)) {
+				zone.NextRadius = m.phases[m.phase].Radius
+				zone.Countdown = math.Max(m.phases[m.phase].Wait - float64(m.phaseTicks) / g.Framerate, 0)
+			}
+	}
+	return zone
+}
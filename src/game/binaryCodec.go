@@ -0,0 +1,715 @@
+package game
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"github.com/gorilla/websocket"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the max value of the quantized position
+const positionSteps = math.MaxUint16
+// define the number of the quantized rotation steps in one turn
+const rotationSteps = 256
+
+/**
+ * BinaryCodec:
+ * The codec to send every message as a compact little-endian binary frame.
+ * Every frame starts with the opcode of the method. The snapshot uses the network ids,
+ * positions quantized to uint16 over the field and rotations quantized to one byte.
+ * The other commands carry their params in json after the opcode.
+ */
+type BinaryCodec struct {}
+
+/**
+ * binaryWriter:
+ * The struct to append the little-endian values to a buffer.
+ *
+ * @property {[]byte} buffer																	- the encoded bytes
+ */
+type binaryWriter struct {
+	buffer []byte
+}
+
+/**
+ * binaryReader:
+ * The struct to read the little-endian values from a buffer, it keeps the first error.
+ *
+ * @property {[]byte} data																		- the bytes to decode
+ * @property {int} offset																			- the offset of the next value
+ * @property {error} err																			- the first error in reading
+ */
+type binaryReader struct {
+	data []byte
+	offset int
+	err error
+}
+
+/**
+ * <*binaryWriter>.u8:
+ * The function in binaryWriter to append the uint8.
+ *
+ * @param {uint8} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) u8 (value uint8) {
+	w.buffer = append(w.buffer, value)
+}
+
+/**
+ * <*binaryWriter>.u16:
+ * The function in binaryWriter to append the uint16.
+ *
+ * @param {uint16} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) u16 (value uint16) {
+	w.buffer = append(w.buffer, 0, 0)
+	binary.LittleEndian.PutUint16(w.buffer[len(w.buffer) - 2:], value)
+}
+
+/**
+ * <*binaryWriter>.u32:
+ * The function in binaryWriter to append the uint32.
+ *
+ * @param {uint32} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) u32 (value uint32) {
+	w.buffer = append(w.buffer, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(w.buffer[len(w.buffer) - 4:], value)
+}
+
+/**
+ * <*binaryWriter>.f32:
+ * The function in binaryWriter to append the value as float32.
+ *
+ * @param {float64} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) f32 (value float64) {
+	w.u32(math.Float32bits(float32(value)))
+}
+
+/**
+ * <*binaryWriter>.f64:
+ * The function in binaryWriter to append the value as float64.
+ *
+ * @param {float64} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) f64 (value float64) {
+	w.buffer = append(w.buffer, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint64(w.buffer[len(w.buffer) - 8:], math.Float64bits(value))
+}
+
+/**
+ * <*binaryWriter>.str:
+ * The function in binaryWriter to append the string with its uint8 length.
+ *
+ * @param {string} value											- the value
+ *
+ * @return {nil}
+ */
+func (w *binaryWriter) str (value string) {
+	// the string is truncated to 255 bytes
+	if (len(value) > math.MaxUint8) {
+		value = value[:math.MaxUint8]
+	}
+	w.u8(uint8(len(value)))
+	w.buffer = append(w.buffer, value...)
+}
+
+/**
+ * <*binaryReader>.next:
+ * The function in binaryReader to take the next n bytes.
+ *
+ * @param {int} n																							- the number of bytes
+ *
+ * @return {[]byte}																						- nil if there are not enough bytes
+ */
+func (r *binaryReader) next (n int) []byte {
+	if (r.err != nil) {
+		return nil
+	}
+	if (r.offset + n > len(r.data)) {
+		r.err = errors.New("Unexpected end of binary message!")
+		return nil
+	}
+	var bytes = r.data[r.offset:r.offset + n]
+	r.offset += n
+	return bytes
+}
+
+/**
+ * <*binaryReader>.u8:
+ * The function in binaryReader to read the uint8.
+ *
+ * @return {uint8}
+ */
+func (r *binaryReader) u8 () uint8 {
+	if bytes := r.next(1); bytes != nil {
+		return bytes[0]
+	}
+	return 0
+}
+
+/**
+ * <*binaryReader>.u16:
+ * The function in binaryReader to read the uint16.
+ *
+ * @return {uint16}
+ */
+func (r *binaryReader) u16 () uint16 {
+	if bytes := r.next(2); bytes != nil {
+		return binary.LittleEndian.Uint16(bytes)
+	}
+	return 0
+}
+
+/**
+ * <*binaryReader>.u32:
+ * The function in binaryReader to read the uint32.
+ *
+ * @return {uint32}
+ */
+func (r *binaryReader) u32 () uint32 {
+	if bytes := r.next(4); bytes != nil {
+		return binary.LittleEndian.Uint32(bytes)
+	}
+	return 0
+}
+
+/**
+ * <*binaryReader>.f32:
+ * The function in binaryReader to read the float32.
+ *
+ * @return {float64}
+ */
+func (r *binaryReader) f32 () float64 {
+	return float64(math.Float32frombits(r.u32()))
+}
+
+/**
+ * <*binaryReader>.f64:
+ * The function in binaryReader to read the float64.
+ *
+ * @return {float64}
+ */
+func (r *binaryReader) f64 () float64 {
+	if bytes := r.next(8); bytes != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(bytes))
+	}
+	return 0
+}
+
+/**
+ * <*binaryReader>.str:
+ * The function in binaryReader to read the string with its uint8 length.
+ *
+ * @return {string}
+ */
+func (r *binaryReader) str () string {
+	return string(r.next(int(r.u8())))
+}
+
+/**
+ * <game>.quantizePosition:
+ * The function to map the coordinate in [0, size] to uint16.
+ *
+ * @param {float64} value																			- the coordinate
+ * @param {float64} size																			- the size of the field on the axis
+ *
+ * @return {uint16}
+ */
+func quantizePosition (value, size float64) uint16 {
+	return uint16(math.Round(math.Max(math.Min(value / size, 1), 0) * positionSteps))
+}
+
+/**
+ * <game>.dequantizePosition:
+ * The function to map the uint16 back to the coordinate in [0, size].
+ *
+ * @param {uint16} value																			- the quantized coordinate
+ * @param {float64} size																			- the size of the field on the axis
+ *
+ * @return {float64}
+ */
+func dequantizePosition (value uint16, size float64) float64 {
+	return float64(value) / positionSteps * size
+}
+
+/**
+ * <game>.quantizeRotation:
+ * The function to map the rotation in radian to one byte.
+ *
+ * @param {float64} value																			- the rotation in radian
+ *
+ * @return {uint8}
+ */
+func quantizeRotation (value float64) uint8 {
+	var turn = math.Mod(value, 2 * math.Pi) / (2 * math.Pi)
+	if (turn < 0) {
+		turn += 1
+	}
+	return uint8(int(math.Round(turn * rotationSteps)) % rotationSteps)
+}
+
+/**
+ * <game>.dequantizeRotation:
+ * The function to map the byte back to the rotation in radian in [0, 2π).
+ *
+ * @param {uint8} value																				- the quantized rotation
+ *
+ * @return {float64}
+ */
+func dequantizeRotation (value uint8) float64 {
+	return float64(value) / rotationSteps * 2 * math.Pi
+}
+
+/**
+ * <BinaryCodec>.Name:
+ * The function in BinaryCodec to get the protocol name.
+ *
+ * @return {string}
+ */
+func (c BinaryCodec) Name () string {
+	return BinaryProtocol
+}
+
+/**
+ * <BinaryCodec>.MessageType:
+ * The function in BinaryCodec to get the websocket frame type.
+ *
+ * @return {int}
+ */
+func (c BinaryCodec) MessageType () int {
+	return websocket.BinaryMessage
+}
+
+/**
+ * <BinaryCodec>.EncodeCommand:
+ * The function in BinaryCodec to encode the command as the opcode followed by the json params.
+ *
+ * @param {PlayerSessionCommand} command											- the command to encode
+ *
+ * @return {[]byte, error}
+ */
+func (c BinaryCodec) EncodeCommand (command PlayerSessionCommand) ([]byte, error) {
+	var writer = binaryWriter {}
+	if opcode, ok := opcodeOf[command.Method]; ok {
+		writer.u8(opcode)
+	} else {
+		writer.u8(0)
+		writer.str(command.Method)
+	}
+	// the empty params are omitted
+	if (len(command.Params) > 0) {
+		params, err := json.Marshal(command.Params)
+		if (err != nil) {
+			return nil, err
+		}
+		writer.buffer = append(writer.buffer, params...)
+	}
+	return writer.buffer, nil
+}
+
+/**
+ * <BinaryCodec>.DecodeCommand:
+ * The function in BinaryCodec to decode the command.
+ *
+ * @param {[]byte} data																				- the data to decode
+ *
+ * @return {PlayerSessionCommand, error}
+ */
+func (c BinaryCodec) DecodeCommand (data []byte) (PlayerSessionCommand, error) {
+	var reader = binaryReader { data: data }
+	var command = PlayerSessionCommand {
+		Params: CommandParams {},
+	}
+	var opcode = int(reader.u8())
+	if (opcode == 0) {
+		command.Method = reader.str()
+	} else if (opcode < len(opcodeTable)) {
+		command.Method = opcodeTable[opcode]
+	} else {
+		return command, errors.New("Unknown opcode in binary message!")
+	}
+	if (reader.err != nil) {
+		return command, reader.err
+	}
+	if (reader.offset < len(data)) {
+		if err := json.Unmarshal(data[reader.offset:], &command.Params); err != nil {
+			return command, err
+		}
+	}
+	return command, nil
+}
+
+/**
+ * <BinaryCodec>.writeEntity:
+ * The function in BinaryCodec to append the entity.
+ *
+ * @param {*binaryWriter} writer												- the writer
+ * @param {EntitySnapshot} entity											- the entity
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {nil}
+ */
+func (c BinaryCodec) writeEntity (writer *binaryWriter, entity EntitySnapshot, field util.Size) {
+	writer.u32(entity.NetId)
+	writer.u16(quantizePosition(entity.X, field.W))
+	writer.u16(quantizePosition(entity.Y, field.H))
+	writer.u8(quantizeRotation(entity.Rotation))
+	writer.u16(uint16(math.Round(math.Max(math.Min(entity.Radius, math.MaxUint16), 0))))
+	writer.u8(uint8(entity.Type))
+}
+
+/**
+ * <BinaryCodec>.readEntity:
+ * The function in BinaryCodec to read the entity.
+ *
+ * @param {*binaryReader} reader												- the reader
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {EntitySnapshot}
+ */
+func (c BinaryCodec) readEntity (reader *binaryReader, field util.Size) EntitySnapshot {
+	return EntitySnapshot {
+		NetId: reader.u32(),
+		X: dequantizePosition(reader.u16(), field.W),
+		Y: dequantizePosition(reader.u16(), field.H),
+		Rotation: dequantizeRotation(reader.u8()),
+		Radius: float64(reader.u16()),
+		Type: int(reader.u8()),
+	}
+}
+
+/**
+ * <BinaryCodec>.writeDiep:
+ * The function in BinaryCodec to append the diep.
+ *
+ * @param {*binaryWriter} writer												- the writer
+ * @param {DiepSnapshot} diep											- the diep
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {nil}
+ */
+func (c BinaryCodec) writeDiep (writer *binaryWriter, diep DiepSnapshot, field util.Size) {
+	c.writeEntity(writer, diep.EntitySnapshot, field)
+	writer.str(diep.Name)
+	writer.str(diep.Class)
+	writer.f32(diep.HP)
+	writer.u8(uint8(diep.Team))
+}
+
+/**
+ * <BinaryCodec>.readDiep:
+ * The function in BinaryCodec to read the diep.
+ *
+ * @param {*binaryReader} reader												- the reader
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {DiepSnapshot}
+ */
+func (c BinaryCodec) readDiep (reader *binaryReader, field util.Size) DiepSnapshot {
+	return DiepSnapshot {
+		EntitySnapshot: c.readEntity(reader, field),
+		Name: reader.str(),
+		Class: reader.str(),
+		HP: reader.f32(),
+		Team: int(reader.u8()),
+	}
+}
+
+/**
+ * <BinaryCodec>.writeEntities:
+ * The function in BinaryCodec to append the u16 counted list of the entities.
+ *
+ * @param {*binaryWriter} writer												- the writer
+ * @param {[]EntitySnapshot} entities											- the entities
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {nil}
+ */
+func (c BinaryCodec) writeEntities (writer *binaryWriter, entities []EntitySnapshot, field util.Size) {
+	// the list is truncated to 65535 items
+	if (len(entities) > math.MaxUint16) {
+		entities = entities[:math.MaxUint16]
+	}
+	writer.u16(uint16(len(entities)))
+	for _, entity := range entities {
+		c.writeEntity(writer, entity, field)
+	}
+}
+
+/**
+ * <BinaryCodec>.readEntities:
+ * The function in BinaryCodec to read the u16 counted list of the entities.
+ *
+ * @param {*binaryReader} reader												- the reader
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {[]EntitySnapshot}
+ */
+func (c BinaryCodec) readEntities (reader *binaryReader, field util.Size) []EntitySnapshot {
+	var count = int(reader.u16())
+	var entities = make([]EntitySnapshot, 0, count)
+	for i := 0; i < count && reader.err == nil; i++ {
+		entities = append(entities, c.readEntity(reader, field))
+	}
+	return entities
+}
+
+/**
+ * <BinaryCodec>.writeUpdate:
+ * The function in BinaryCodec to append the update with the fields in its mask.
+ *
+ * @param {*binaryWriter} writer												- the writer
+ * @param {EntityUpdate} update												- the update
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {nil}
+ */
+func (c BinaryCodec) writeUpdate (writer *binaryWriter, update EntityUpdate, field util.Size) {
+	writer.u32(update.NetId)
+	writer.u8(update.Mask)
+	if (update.Mask & updatePosition != 0) {
+		writer.u16(quantizePosition(update.X, field.W))
+		writer.u16(quantizePosition(update.Y, field.H))
+	}
+	if (update.Mask & updateRotation != 0) {
+		writer.u8(quantizeRotation(update.Rotation))
+	}
+	if (update.Mask & updateRadius != 0) {
+		writer.u16(uint16(math.Round(math.Max(math.Min(update.Radius, math.MaxUint16), 0))))
+	}
+	if (update.Mask & updateHP != 0) {
+		writer.f32(update.HP)
+	}
+	if (update.Mask & updateClass != 0) {
+		writer.str(update.Class)
+	}
+}
+
+/**
+ * <BinaryCodec>.readUpdate:
+ * The function in BinaryCodec to read the update with the fields in its mask.
+ *
+ * @param {*binaryReader} reader												- the reader
+ * @param {util.Size} field													- the size of the game field
+ *
+ * @return {EntityUpdate}
+ */
+func (c BinaryCodec) readUpdate (reader *binaryReader, field util.Size) EntityUpdate {
+	var update = EntityUpdate {
+		NetId: reader.u32(),
+		Mask: reader.u8(),
+	}
+	if (update.Mask & updatePosition != 0) {
+		update.X = dequantizePosition(reader.u16(), field.W)
+		update.Y = dequantizePosition(reader.u16(), field.H)
+	}
+	if (update.Mask & updateRotation != 0) {
+		update.Rotation = dequantizeRotation(reader.u8())
+	}
+	if (update.Mask & updateRadius != 0) {
+		update.Radius = float64(reader.u16())
+	}
+	if (update.Mask & updateHP != 0) {
+		update.HP = reader.f32()
+	}
+	if (update.Mask & updateClass != 0) {
+		update.Class = reader.str()
+	}
+	return update
+}
+
+/**
+ * <BinaryCodec>.writeZone:
+ * The function in BinaryCodec to append the safe zone, only the active flag if there is no zone.
+ *
+ * @param {*binaryWriter} writer												- the writer
+ * @param {ZoneSnapshot} zone													- the zone
+ *
+ * @return {nil}
+ */
+func (c BinaryCodec) writeZone (writer *binaryWriter, zone ZoneSnapshot) {
+	if (!zone.Active) {
+		writer.u8(0)
+		return
+	}
+	writer.u8(1)
+	writer.u8(uint8(zone.Phase))
+	for _, value := range []float64 { zone.X, zone.Y, zone.Radius, zone.NextX, zone.NextY, zone.NextRadius, zone.Countdown } {
+		writer.f32(value)
+	}
+}
+
+/**
+ * <BinaryCodec>.readZone:
+ * The function in BinaryCodec to read the safe zone.
+ *
+ * @param {*binaryReader} reader												- the reader
+ *
+ * @return {ZoneSnapshot}
+ */
+func (c BinaryCodec) readZone (reader *binaryReader) ZoneSnapshot {
+	var zone = ZoneSnapshot {}
+	if (reader.u8() == 0) {
+		return zone
+	}
+	zone.Active = true
+	zone.Phase = int(reader.u8())
+	for _, value := range []*float64 { &zone.X, &zone.Y, &zone.Radius, &zone.NextX, &zone.NextY, &zone.NextRadius, &zone.Countdown } {
+		*value = reader.f32()
+	}
+	return zone
+}
+
+/**
+ * <BinaryCodec>.EncodeSnapshot:
+ * The function in BinaryCodec to encode the snapshot.
+ * The layout is: opcode, u32 tick, u32 base tick, u32 input seq, f64 input time, the u8 counted u32 team scores,
+ * the zone, f32 field size, the player, then the u16 counted lists of created dieps, stuffs, traps and bullets,
+ * updates and destroyed net ids. An entity is u32 net id, u16 x, u16 y, u8 rotation, u16 radius and
+ * u8 type, and a diep adds its name, class, f32 HP and u8 team. The zone is u8 active, and if active u8 phase,
+ * f32 x, y, radius, next x, next y, next radius and countdown. An update is u32 net id and u8 mask followed by the changed fields in the same format.
+ *
+ * @param {*Snapshot} snapshot																- the snapshot to encode
+ *
+ * @return {[]byte, error}
+ */
+func (c BinaryCodec) EncodeSnapshot (snapshot *Snapshot) ([]byte, error) {
+	if (snapshot.Field.W <= 0 || snapshot.Field.H <= 0) {
+		return nil, errors.New("The snapshot field size must be positive!")
+	}
+	var writer = binaryWriter {
+		buffer: make([]byte, 0, 64 + 12 * (len(snapshot.Stuffs) + len(snapshot.Traps) + len(snapshot.Bullets)) + 32 * len(snapshot.Dieps)),
+	}
+	writer.u8(opcodeOf["playerSession"])
+	writer.u32(uint32(snapshot.Tick))
+	writer.u32(uint32(snapshot.BaseTick))
+	writer.u32(snapshot.InputSeq)
+	writer.f64(snapshot.InputTime)
+	var scores = snapshot.TeamScores
+	if (len(scores) > math.MaxUint8) {
+		scores = scores[:math.MaxUint8]
+	}
+	writer.u8(uint8(len(scores)))
+	for _, score := range scores {
+		writer.u32(uint32(score))
+	}
+	c.writeZone(&writer, snapshot.Zone)
+	writer.f32(snapshot.Field.W)
+	writer.f32(snapshot.Field.H)
+	// the own player
+	c.writeDiep(&writer, snapshot.Player.DiepSnapshot, snapshot.Field)
+	writer.u16(uint16(snapshot.Player.Level))
+	writer.u32(uint32(snapshot.Player.EXP))
+	writer.u32(uint32(snapshot.Player.Score))
+	writer.u16(uint16(snapshot.Player.SkillPoint))
+	var status = snapshot.Player.Status
+	for _, value := range []int { status.MaxHP, status.HPRegeneration, status.MoveSpeed, status.BulletSpeed, status.BulletPenetration, status.BulletReload, status.BulletDamage, status.BodyDamage } {
+		writer.u8(uint8(value))
+	}
+	// the items in view
+	var dieps = snapshot.Dieps
+	if (len(dieps) > math.MaxUint16) {
+		dieps = dieps[:math.MaxUint16]
+	}
+	writer.u16(uint16(len(dieps)))
+	for _, diep := range dieps {
+		c.writeDiep(&writer, diep, snapshot.Field)
+	}
+	c.writeEntities(&writer, snapshot.Stuffs, snapshot.Field)
+	c.writeEntities(&writer, snapshot.Traps, snapshot.Field)
+	c.writeEntities(&writer, snapshot.Bullets, snapshot.Field)
+	// the changes since the baseline
+	var updates = snapshot.Updates
+	if (len(updates) > math.MaxUint16) {
+		updates = updates[:math.MaxUint16]
+	}
+	writer.u16(uint16(len(updates)))
+	for _, update := range updates {
+		c.writeUpdate(&writer, update, snapshot.Field)
+	}
+	var destroyed = snapshot.Destroyed
+	if (len(destroyed) > math.MaxUint16) {
+		destroyed = destroyed[:math.MaxUint16]
+	}
+	writer.u16(uint16(len(destroyed)))
+	for _, id := range destroyed {
+		writer.u32(id)
+	}
+	return writer.buffer, nil
+}
+
+/**
+ * <BinaryCodec>.DecodeSnapshot:
+ * The function in BinaryCodec to decode the snapshot.
+ *
+ * @param {[]byte} data																				- the data to decode
+ *
+ * @return {*Snapshot, error}
+ */
+func (c BinaryCodec) DecodeSnapshot (data []byte) (*Snapshot, error) {
+	var reader = binaryReader { data: data }
+	if (reader.u8() != opcodeOf["playerSession"]) {
+		return nil, errors.New("Not a snapshot message!")
+	}
+	var snapshot = Snapshot {}
+	snapshot.Tick = uint64(reader.u32())
+	snapshot.BaseTick = uint64(reader.u32())
+	snapshot.InputSeq = reader.u32()
+	snapshot.InputTime = reader.f64()
+	var score_count = int(reader.u8())
+	snapshot.TeamScores = make([]int, 0, score_count)
+	for i := 0; i < score_count && reader.err == nil; i++ {
+		snapshot.TeamScores = append(snapshot.TeamScores, int(reader.u32()))
+	}
+	snapshot.Zone = c.readZone(&reader)
+	snapshot.Field.W = reader.f32()
+	snapshot.Field.H = reader.f32()
+	snapshot.Player.DiepSnapshot = c.readDiep(&reader, snapshot.Field)
+	snapshot.Player.Level = int(reader.u16())
+	snapshot.Player.EXP = int(reader.u32())
+	snapshot.Player.Score = int(reader.u32())
+	snapshot.Player.SkillPoint = int(reader.u16())
+	var status = &snapshot.Player.Status
+	for _, value := range []*int { &status.MaxHP, &status.HPRegeneration, &status.MoveSpeed, &status.BulletSpeed, &status.BulletPenetration, &status.BulletReload, &status.BulletDamage, &status.BodyDamage } {
+		*value = int(reader.u8())
+	}
+	var diep_count = int(reader.u16())
+	snapshot.Dieps = make([]DiepSnapshot, 0, diep_count)
+	for i := 0; i < diep_count && reader.err == nil; i++ {
+		snapshot.Dieps = append(snapshot.Dieps, c.readDiep(&reader, snapshot.Field))
+	}
+	snapshot.Stuffs = c.readEntities(&reader, snapshot.Field)
+	snapshot.Traps = c.readEntities(&reader, snapshot.Field)
+	snapshot.Bullets = c.readEntities(&reader, snapshot.Field)
+	var update_count = int(reader.u16())
+	snapshot.Updates = make([]EntityUpdate, 0, update_count)
+	for i := 0; i < update_count && reader.err == nil; i++ {
+		snapshot.Updates = append(snapshot.Updates, c.readUpdate(&reader, snapshot.Field))
+	}
+	var destroyed_count = int(reader.u16())
+	snapshot.Destroyed = make([]uint32, 0, destroyed_count)
+	for i := 0; i < destroyed_count && reader.err == nil; i++ {
+		snapshot.Destroyed = append(snapshot.Destroyed, reader.u32())
+	}
+	if (reader.err != nil) {
+		return nil, reader.err
+	}
+	if (reader.offset < len(data)) {
+		return nil, errors.New("Unexpected data after the snapshot!")
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,317 @@
+package game
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+/**
+ * <game>.testSnapshot:
+ * The function to get the snapshot with every section filled for the codec tests.
+ * The floats not quantized are exact in float32, so they survive the binary codec unchanged.
+ *
+ * @param {bool} delta																				- true for the delta snapshot against a baseline, false for the full one
+ *
+ * @return {*Snapshot}
+ */
+func testSnapshot (delta bool) *Snapshot {
+	var snapshot = &Snapshot {
+		Tick: 1200,
+		InputSeq: 42,
+		InputTime: 1700000000123.25,
+		TeamScores: []int { 3, 7 },
+		Zone: ZoneSnapshot {
+			Active: true,
+			Phase: 2,
+			X: 4096,
+			Y: 2048.5,
+			Radius: 1500,
+			NextX: 4000,
+			NextY: 2100,
+			NextRadius: 900,
+			Countdown: 12.5,
+		},
+		Field: util.Size { W: 8192, H: 4096 },
+		Player: PlayerSnapshot {
+			DiepSnapshot: DiepSnapshot {
+				EntitySnapshot: EntitySnapshot { NetId: 1, X: 1234.56, Y: 789.01, Rotation: 1.2345, Radius: 50 },
+				Name: "player",
+				Class: "Twin",
+				HP: 87.5,
+				Team: 1,
+			},
+			Level: 12,
+			EXP: 3456,
+			Score: 9876,
+			SkillPoint: 3,
+			Status: PlayerStatus { 1, 2, 3, 4, 5, 6, 7, 0 },
+		},
+		Dieps: []DiepSnapshot {},
+		Stuffs: []EntitySnapshot {},
+		Traps: []EntitySnapshot {},
+		Bullets: []EntitySnapshot {},
+		Updates: []EntityUpdate {},
+		Destroyed: []uint32 {},
+	}
+	if (!delta) {
+		snapshot.Dieps = []DiepSnapshot {
+			{
+				EntitySnapshot: EntitySnapshot { NetId: 2, X: 0, Y: 4096, Rotation: 6.2, Radius: 60 },
+				Name: "enemy",
+				Class: "Sniper",
+				HP: 12.25,
+				Team: 2,
+			},
+		}
+		snapshot.Stuffs = []EntitySnapshot {
+			{ NetId: 3, X: 100.1, Y: 200.2, Rotation: 0.5, Radius: 20, Type: 1 },
+			{ NetId: 4, X: 8191.9, Y: 0.3, Rotation: 3.3, Radius: 30, Type: 2 },
+		}
+		snapshot.Traps = []EntitySnapshot {
+			{ NetId: 5, X: 5000, Y: 3000, Rotation: 0, Radius: 40, Type: 3 },
+		}
+		snapshot.Bullets = []EntitySnapshot {
+			{ NetId: 6, X: 1300.7, Y: 800.9, Rotation: 4.71, Radius: 10 },
+		}
+		return snapshot
+	}
+	snapshot.BaseTick = 1190
+	snapshot.Updates = []EntityUpdate {
+		{ NetId: 2, Mask: updatePosition | updateRotation, X: 2500.5, Y: 1000.25, Rotation: 2.5 },
+		{ NetId: 3, Mask: updateHP | updateRadius, Radius: 25, HP: 50.5 },
+		{ NetId: 7, Mask: updateClass, Class: "Machine Gun" },
+	}
+	snapshot.Destroyed = []uint32 { 4, 5, 6 }
+	return snapshot
+}
+
+/**
+ * <game>.angleDistance:
+ * The function to get the distance between two angles on the circle.
+ *
+ * @param {float64} a																					- the angle in radian
+ * @param {float64} b																					- the other angle in radian
+ *
+ * @return {float64}
+ */
+func angleDistance (a, b float64) float64 {
+	var distance = math.Mod(math.Abs(a - b), 2 * math.Pi)
+	return math.Min(distance, 2 * math.Pi - distance)
+}
+
+/**
+ * <game>.checkEntity:
+ * The function to check the decoded entity against the original within the quantization tolerance.
+ *
+ * @param {*testing.T} t																			- the test
+ * @param {EntitySnapshot} got																- the decoded entity
+ * @param {EntitySnapshot} want																- the original entity
+ * @param {util.Size} field																		- the field size of the snapshot
+ *
+ * @return {nil}
+ */
+func checkEntity (t *testing.T, got, want EntitySnapshot, field util.Size) {
+	t.Helper()
+	if (got.NetId != want.NetId) || (got.Radius != want.Radius) || (got.Type != want.Type) {
+		t.Errorf("entity decoded as %+v, want %+v", got, want)
+	}
+	if (math.Abs(got.X - want.X) > field.W / positionSteps) || (math.Abs(got.Y - want.Y) > field.H / positionSteps) {
+		t.Errorf("entity %d at (%v, %v), want (%v, %v)", want.NetId, got.X, got.Y, want.X, want.Y)
+	}
+	if (angleDistance(got.Rotation, want.Rotation) > math.Pi / rotationSteps) {
+		t.Errorf("entity %d rotation %v, want %v", want.NetId, got.Rotation, want.Rotation)
+	}
+}
+
+/**
+ * <game>.checkDiep:
+ * The function to check the decoded diep against the original.
+ *
+ * @param {*testing.T} t																			- the test
+ * @param {DiepSnapshot} got																	- the decoded diep
+ * @param {DiepSnapshot} want																	- the original diep
+ * @param {util.Size} field																		- the field size of the snapshot
+ *
+ * @return {nil}
+ */
+func checkDiep (t *testing.T, got, want DiepSnapshot, field util.Size) {
+	t.Helper()
+	checkEntity(t, got.EntitySnapshot, want.EntitySnapshot, field)
+	if (got.Name != want.Name) || (got.Class != want.Class) || (got.HP != want.HP) || (got.Team != want.Team) {
+		t.Errorf("diep decoded as %+v, want %+v", got, want)
+	}
+}
+
+/**
+ * <game>.checkEntities:
+ * The function to check the decoded entity list against the original.
+ *
+ * @param {*testing.T} t																			- the test
+ * @param {[]EntitySnapshot} got															- the decoded entities
+ * @param {[]EntitySnapshot} want															- the original entities
+ * @param {util.Size} field																		- the field size of the snapshot
+ *
+ * @return {nil}
+ */
+func checkEntities (t *testing.T, got, want []EntitySnapshot, field util.Size) {
+	t.Helper()
+	if (len(got) != len(want)) {
+		t.Fatalf("%d entities decoded, want %d", len(got), len(want))
+	}
+	for i := range want {
+		checkEntity(t, got[i], want[i], field)
+	}
+}
+
+/**
+ * <game>.TestBinarySnapshotRoundTrip:
+ * The test to check the full and delta snapshots decode back to the encoded ones within the quantization tolerance.
+ */
+func TestBinarySnapshotRoundTrip (t *testing.T) {
+	for _, delta := range []bool { false, true } {
+		var want = testSnapshot(delta)
+		data, err := BinaryCodec {}.EncodeSnapshot(want)
+		if (err != nil) {
+			t.Fatalf("delta %v: encode: %v", delta, err)
+		}
+		got, err := BinaryCodec {}.DecodeSnapshot(data)
+		if (err != nil) {
+			t.Fatalf("delta %v: decode: %v", delta, err)
+		}
+		if (got.Tick != want.Tick) || (got.BaseTick != want.BaseTick) || (got.InputSeq != want.InputSeq) || (got.InputTime != want.InputTime) {
+			t.Errorf("delta %v: header decoded as %d %d %d %v", delta, got.Tick, got.BaseTick, got.InputSeq, got.InputTime)
+		}
+		if (!reflect.DeepEqual(got.TeamScores, want.TeamScores)) || (got.Zone != want.Zone) || (got.Field != want.Field) {
+			t.Errorf("delta %v: scores %v zone %+v field %+v", delta, got.TeamScores, got.Zone, got.Field)
+		}
+		checkDiep(t, got.Player.DiepSnapshot, want.Player.DiepSnapshot, want.Field)
+		if (got.Player.Level != want.Player.Level) || (got.Player.EXP != want.Player.EXP) || (got.Player.Score != want.Player.Score) ||
+			(got.Player.SkillPoint != want.Player.SkillPoint) || (got.Player.Status != want.Player.Status) {
+			t.Errorf("delta %v: player decoded as %+v, want %+v", delta, got.Player, want.Player)
+		}
+		if (len(got.Dieps) != len(want.Dieps)) {
+			t.Fatalf("delta %v: %d dieps decoded, want %d", delta, len(got.Dieps), len(want.Dieps))
+		}
+		for i := range want.Dieps {
+			checkDiep(t, got.Dieps[i], want.Dieps[i], want.Field)
+		}
+		checkEntities(t, got.Stuffs, want.Stuffs, want.Field)
+		checkEntities(t, got.Traps, want.Traps, want.Field)
+		checkEntities(t, got.Bullets, want.Bullets, want.Field)
+		if (len(got.Updates) != len(want.Updates)) {
+			t.Fatalf("delta %v: %d updates decoded, want %d", delta, len(got.Updates), len(want.Updates))
+		}
+		for i, update := range want.Updates {
+			var decoded = got.Updates[i]
+			if (decoded.NetId != update.NetId) || (decoded.Mask != update.Mask) || (decoded.Radius != update.Radius) ||
+				(decoded.HP != update.HP) || (decoded.Class != update.Class) {
+				t.Errorf("delta %v: update decoded as %+v, want %+v", delta, decoded, update)
+			}
+			if (math.Abs(decoded.X - update.X) > want.Field.W / positionSteps) || (math.Abs(decoded.Y - update.Y) > want.Field.H / positionSteps) ||
+				(angleDistance(decoded.Rotation, update.Rotation) > math.Pi / rotationSteps) {
+				t.Errorf("delta %v: update %d moved to (%v, %v, %v), want (%v, %v, %v)", delta, update.NetId, decoded.X, decoded.Y, decoded.Rotation, update.X, update.Y, update.Rotation)
+			}
+		}
+		if (!reflect.DeepEqual(got.Destroyed, want.Destroyed)) {
+			t.Errorf("delta %v: destroyed %v, want %v", delta, got.Destroyed, want.Destroyed)
+		}
+	}
+}
+
+/**
+ * <game>.TestBinaryCommandRoundTrip:
+ * The test to check the commands with an opcode and the ones sent by the method name decode back unchanged.
+ */
+func TestBinaryCommandRoundTrip (t *testing.T) {
+	var commands = []PlayerSessionCommand {
+		{ Method: "shoot", Params: CommandParams { "angle": 1.5, "seq": 7.0, "time": 123.5 } },
+		{ Method: "moveUp", Params: CommandParams { "value": true } },
+		{ Method: "respawn", Params: CommandParams {} },
+		{ Method: "customMethod", Params: CommandParams { "text": "hello" } },
+		{ Method: "emptyCustomMethod", Params: CommandParams {} },
+	}
+	for _, want := range commands {
+		data, err := BinaryCodec {}.EncodeCommand(want)
+		if (err != nil) {
+			t.Fatalf("%s: encode: %v", want.Method, err)
+		}
+		var opcode, known = opcodeOf[want.Method]
+		if (!known) {
+			opcode = 0
+		}
+		if (data[0] != opcode) {
+			t.Errorf("%s: encoded with opcode %d, want %d", want.Method, data[0], opcode)
+		}
+		got, err := BinaryCodec {}.DecodeCommand(data)
+		if (err != nil) {
+			t.Fatalf("%s: decode: %v", want.Method, err)
+		}
+		if (!reflect.DeepEqual(got, want)) {
+			t.Errorf("decoded as %+v, want %+v", got, want)
+		}
+	}
+}
+
+/**
+ * <game>.TestBinarySnapshotTruncated:
+ * The test to check every cut of the snapshot is rejected instead of read out of bounds.
+ */
+func TestBinarySnapshotTruncated (t *testing.T) {
+	for _, delta := range []bool { false, true } {
+		data, err := BinaryCodec {}.EncodeSnapshot(testSnapshot(delta))
+		if (err != nil) {
+			t.Fatal(err)
+		}
+		for length := 0; length < len(data); length++ {
+			if _, err := (BinaryCodec {}).DecodeSnapshot(data[:length]); err == nil {
+				t.Errorf("delta %v: snapshot cut to %d of %d bytes decoded without error", delta, length, len(data))
+			}
+		}
+	}
+}
+
+/**
+ * <game>.TestBinarySnapshotOversized:
+ * The test to check the snapshots with the trailing bytes or the counts beyond the data are rejected.
+ */
+func TestBinarySnapshotOversized (t *testing.T) {
+	data, err := BinaryCodec {}.EncodeSnapshot(testSnapshot(true))
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if _, err := (BinaryCodec {}).DecodeSnapshot(append(append([]byte {}, data...), 0, 0, 0)); err == nil {
+		t.Error("snapshot with trailing bytes decoded without error")
+	}
+	// the destroyed count is the last field before the ids
+	var inflated = append([]byte {}, data...)
+	var count_offset = len(inflated) - 4 * len(testSnapshot(true).Destroyed) - 2
+	inflated[count_offset] = 0xff
+	inflated[count_offset + 1] = 0xff
+	if _, err := (BinaryCodec {}).DecodeSnapshot(inflated); err == nil {
+		t.Error("snapshot with the destroyed count beyond the data decoded without error")
+	}
+	if _, err := (BinaryCodec {}).DecodeSnapshot([]byte { opcodeOf["ping"] }); err == nil {
+		t.Error("ping decoded as a snapshot")
+	}
+}
+
+/**
+ * <game>.TestBinaryCommandMalformed:
+ * The test to check the truncated method names, the unknown opcodes and the broken params are rejected.
+ */
+func TestBinaryCommandMalformed (t *testing.T) {
+	var cases = map[string][]byte {
+		"empty": {},
+		"method name beyond the data": { 0, 200, 'a', 'b', 'c' },
+		"missing method name": { 0 },
+		"unknown opcode": { byte(len(opcodeTable)) },
+		"broken params": append([]byte { opcodeOf["shoot"] }, `{"angle":`...),
+	}
+	for name, data := range cases {
+		if _, err := (BinaryCodec {}).DecodeCommand(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
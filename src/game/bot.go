@@ -0,0 +1,185 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// define the time between two decisions of the bots
+const botThinkInterval = 100 * time.Millisecond
+// define how far the bot can see
+const botSightRange = 800
+
+/**
+ * <game>.newBotSession:
+ * The function to new a session without connection for the bot player.
+ *
+ * @param {*Game} game																				- the game room of the bot
+ * @param {string} name																				- the name of the bot
+ * @param {BotBehavior} behavior															- the behavior to drive the bot
+ *
+ * @return {*PlayerSession}
+ */
+func newBotSession (game *Game, name string, behavior BotBehavior) *PlayerSession {
+	return &PlayerSession {
+		Game: game,
+		Player: NewPlayer(name),
+		MBus: make (chan bool, 1),
+		Alive: true,
+		Codec: JSONCodec {},
+		outbound: make (chan outboundMessage, outboundQueueSize),
+		wake: make (chan bool, 1),
+		closed: make (chan bool),
+		writerDone: make (chan bool),
+		bot: behavior,
+	}
+}
+
+/**
+ * <*PlayerSession>.IsBot:
+ * The function in PlayerSession to check if the session is driven by the server.
+ *
+ * @return {bool}
+ */
+func (ps *PlayerSession) IsBot () bool {
+	return ps.bot != nil
+}
+
+/**
+ * <*Game>.humanCount:
+ * The function in Game to get the number of the sessions of the real players.
+ * The caller should hold the game lock.
+ *
+ * @return {int}
+ */
+func (g *Game) humanCount () int {
+	var count = 0
+	for _, ps := range g.Sessions {
+		if (!ps.IsBot()) {
+			count++
+		}
+	}
+	return count
+}
+
+/**
+ * <*Game>.balanceBots:
+ * The function in Game to add or remove the bots to fill the room up to the bot count, the dead bots respawn by themselves.
+ * The players waiting for resuming keep their seats. The caller should hold the game lock.
+ *
+ * @return {nil}
+ */
+func (g *Game) balanceBots () {
+	var target = g.Config.BotCount - g.humanCount() - len(g.suspended)
+	var bots = 0
+	var sessions = g.Sessions[:0]
+	for _, ps := range g.Sessions {
+		if (ps.IsBot()) {
+			// remove the dropped bots and the bots over the target
+			if (!ps.Alive || bots >= target) {
+				g.removePlayer(ps.Player)
+				log.Printf("Bot player %s left game room %s", ps.Player.Attr.Name, g.Name)
+				continue
+			}
+			bots++
+		}
+		sessions = append(sessions, ps)
+	}
+	g.Sessions = sessions
+	// the game mode may close the field to the newcomers
+	for ; bots < target && g.Mode.CanJoin(g); bots++ {
+		g.nextBotId++
+		var name = fmt.Sprintf("Bot %d", g.nextBotId)
+		var session = newBotSession(g, name, NewDefaultBotBehavior())
+		g.Sessions = append(g.Sessions, session)
+		g.addPlayer(session.Player)
+		log.Printf("Bot player %s joined to game room %s", name, g.Name)
+	}
+}
+
+/**
+ * <*Game>.runBots:
+ * The function in Game to keep the bots deciding and sending their commands like the clients.
+ *
+ * @return {nil}
+ */
+func (g *Game) runBots () {
+	defer g.routines.Done()
+	for g.sleep(botThinkInterval) {
+		var bots = []*PlayerSession {}
+		var views = []BotView {}
+		var dead = []*PlayerSession {}
+		g.ControlLock.Lock()
+		for _, ps := range g.Sessions {
+			if (!ps.IsBot()) || (!ps.Alive) {
+				continue
+			}
+			if (ps.Dead) {
+				// respawn at once if the game mode allows
+				if _, ok := g.Mode.RespawnLevel(g, ps.Player); ok {
+					dead = append(dead, ps)
+				}
+				continue
+			}
+			bots = append(bots, ps)
+			views = append(views, g.botView(ps))
+		}
+		g.ControlLock.Unlock()
+		// serve the commands without the game lock as the receiver does
+		for index, ps := range bots {
+			for _, command := range ps.bot.Think(views[index]) {
+				ps.serveCommand(command)
+			}
+		}
+		for _, ps := range dead {
+			ps.serveCommand(PlayerSessionCommand {
+				Method: "respawn",
+				Params: CommandParams {},
+			})
+		}
+	}
+}
+
+/**
+ * <*Game>.botView:
+ * The function in Game to copy what the bot can see from the game.
+ * The caller should hold the game lock.
+ *
+ * @param {*PlayerSession} ps																	- the bot session
+ *
+ * @return {BotView}
+ */
+func (g *Game) botView (ps *PlayerSession) BotView {
+	var position = ps.Player.GameObject.Position
+	var view = BotView {
+		Position: position,
+		HP: ps.Player.Attr.HP,
+		Field: *g.Field,
+		Dieps: []BotTarget {},
+		Stuffs: []BotTarget {},
+	}
+	for _, object := range g.MapInfo.Index.Dieps.QueryCircle(position, botSightRange) {
+		var diep = object.(*Diep)
+		var session = g.findSession(diep.GameObject.Id)
+		// skip itself, the frozen, the dead tanks and the teammates
+		if (session == nil) || (session == ps) || (!session.Playing()) || (!g.Mode.IsEnemy(g, ps.Player, session.Player)) {
+			continue
+		}
+		view.Dieps = append(view.Dieps, BotTarget {
+			Name: diep.Player.Attr.Name,
+			Position: diep.Position,
+			Radius: diep.Radius,
+			HP: diep.Player.Attr.HP,
+		})
+	}
+	for _, object := range g.MapInfo.Index.Stuffs.QueryCircle(position, botSightRange) {
+		var stuff = object.(*Stuff)
+		view.Stuffs = append(view.Stuffs, BotTarget {
+			Position: stuff.Position,
+			Radius: stuff.Radius,
+			HP: stuff.Attr.HP,
+		})
+	}
+	return view
+}
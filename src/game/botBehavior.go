@@ -0,0 +1,252 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the HP below which the bot runs away from the other tanks
+const botFleeHP = 30
+// define the distance the hunting bot keeps from its target
+const botHuntDistance = 400
+// define the distance the wandering bot counts as reaching the point
+const botWanderReach = 200
+
+/**
+ * BotTarget:
+ * The struct of one item the bot can see.
+ *
+ * @property {string} Name																		- the name of the player, empty for the stuffs
+ * @property {util.Vec2} Position															- the position of the item
+ * @property {float64} Radius																	- the collision circle radius
+ * @property {float64} HP																			- the HP of the item
+ */
+type BotTarget struct {
+	Name string
+	Position util.Vec2
+	Radius float64
+	HP float64
+}
+
+/**
+ * BotView:
+ * The struct of what the bot knows to decide the next commands, copied from the game in every think.
+ *
+ * @property {util.Vec2} Position															- the position of the bot
+ * @property {float64} HP																			- the HP of the bot
+ * @property {util.Size} Field																- the size of the game field
+ * @property {[]BotTarget} Dieps															- the other tanks in sight
+ * @property {[]BotTarget} Stuffs															- the stuffs in sight
+ */
+type BotView struct {
+	Position util.Vec2
+	HP float64
+	Field util.Size
+	Dieps []BotTarget
+	Stuffs []BotTarget
+}
+
+/**
+ * BotBehavior:
+ * The interface to drive a bot with the same commands as the client sends.
+ * Think returns nil if the behavior has nothing to do, so the next behavior in a BotChain decides.
+ */
+type BotBehavior interface {
+	Think(view BotView) []PlayerSessionCommand
+}
+
+/**
+ * BotChain:
+ * The behavior to ask the behaviors in order and take the first one with commands.
+ */
+type BotChain []BotBehavior
+
+/**
+ * FarmBehavior:
+ * The behavior to wander around the field, and ram the nearest stuff since only the body damages the stuffs.
+ *
+ * @property {util.Vec2} wander																- the point to wander to if no stuff is in sight
+ */
+type FarmBehavior struct {
+	wander util.Vec2
+}
+
+/**
+ * HuntBehavior:
+ * The behavior to chase and shoot the weakest tank in sight.
+ */
+type HuntBehavior struct {}
+
+/**
+ * FleeBehavior:
+ * The behavior to run away from the nearest tank at low HP.
+ */
+type FleeBehavior struct {}
+
+/**
+ * <game>.NewDefaultBotBehavior:
+ * The function to get the behavior of the room bots, which flee at low HP, hunt if any tank is in sight and farm otherwise.
+ *
+ * @return {BotBehavior}
+ */
+func NewDefaultBotBehavior () BotBehavior {
+	return BotChain {
+		&FleeBehavior {},
+		&HuntBehavior {},
+		&FarmBehavior {},
+	}
+}
+
+/**
+ * <BotChain>.Think:
+ * The function in BotChain to get the commands of the first behavior with any.
+ *
+ * @param {BotView} view																			- what the bot knows
+ *
+ * @return {[]PlayerSessionCommand}
+ */
+func (c BotChain) Think (view BotView) []PlayerSessionCommand {
+	for _, behavior := range c {
+		if commands := behavior.Think(view); commands != nil {
+			return commands
+		}
+	}
+	return nil
+}
+
+/**
+ * <*FarmBehavior>.Think:
+ * The function in FarmBehavior to get the commands to farm the nearest stuff.
+ *
+ * @param {BotView} view																			- what the bot knows
+ *
+ * @return {[]PlayerSessionCommand}
+ */
+func (b *FarmBehavior) Think (view BotView) []PlayerSessionCommand {
+	target, ok := nearestTarget(view.Position, view.Stuffs)
+	if (!ok) {
+		// pick another point when the last one is reached
+		if (b.wander == util.Vec2 {}) || (b.wander.Sub(view.Position).Length() < botWanderReach) {
+			b.wander = util.Vec2 { X: rand.Float64() * view.Field.W, Y: rand.Float64() * view.Field.H }
+		}
+		return botMove(b.wander.Sub(view.Position))
+	}
+	return botMove(target.Position.Sub(view.Position))
+}
+
+/**
+ * <*HuntBehavior>.Think:
+ * The function in HuntBehavior to get the commands to chase and shoot the weakest tank.
+ *
+ * @param {BotView} view																			- what the bot knows
+ *
+ * @return {[]PlayerSessionCommand}																- nil if no tank is in sight
+ */
+func (b *HuntBehavior) Think (view BotView) []PlayerSessionCommand {
+	if (len(view.Dieps) == 0) {
+		return nil
+	}
+	var target = view.Dieps[0]
+	for _, diep := range view.Dieps[1:] {
+		if (diep.HP < target.HP) {
+			target = diep
+		}
+	}
+	var offset = target.Position.Sub(view.Position)
+	// keep the distance to shoot without ramming
+	var direction = offset
+	if (offset.Length() < botHuntDistance) {
+		direction = offset.Scale(-1)
+	}
+	return append(botMove(direction), botShoot(offset))
+}
+
+/**
+ * <*FleeBehavior>.Think:
+ * The function in FleeBehavior to get the commands to run away from the nearest tank.
+ *
+ * @param {BotView} view																			- what the bot knows
+ *
+ * @return {[]PlayerSessionCommand}																- nil if the HP is not low or no tank is in sight
+ */
+func (b *FleeBehavior) Think (view BotView) []PlayerSessionCommand {
+	if (view.HP >= botFleeHP) {
+		return nil
+	}
+	target, ok := nearestTarget(view.Position, view.Dieps)
+	if (!ok) {
+		return nil
+	}
+	var offset = target.Position.Sub(view.Position)
+	// shoot back while running away
+	return append(botMove(offset.Scale(-1)), botShoot(offset))
+}
+
+/**
+ * <game>.nearestTarget:
+ * The function to find the target nearest to the position.
+ *
+ * @param {util.Vec2} position																- the position to measure from
+ * @param {[]BotTarget} targets																- the targets
+ *
+ * @return {BotTarget, bool}																	- false if there is no target
+ */
+func nearestTarget (position util.Vec2, targets []BotTarget) (BotTarget, bool) {
+	var nearest BotTarget
+	var distance = math.Inf(1)
+	for _, target := range targets {
+		if length := target.Position.Sub(position).Length(); length < distance {
+			nearest = target
+			distance = length
+		}
+	}
+	return nearest, !math.IsInf(distance, 1)
+}
+
+/**
+ * <game>.botMove:
+ * The function to get the move commands toward the direction, the zero direction stops the bot.
+ *
+ * @param {util.Vec2} direction																- the direction to move
+ *
+ * @return {[]PlayerSessionCommand}
+ */
+func botMove (direction util.Vec2) []PlayerSessionCommand {
+	direction = direction.Normalize()
+	// move along the axis only if the direction leans enough to it
+	var threshold = math.Sin(math.Pi / 8)
+	var moving = map[string]bool {
+		"moveUp": direction.Y < -threshold,
+		"moveDown": direction.Y > threshold,
+		"moveLeft": direction.X < -threshold,
+		"moveRight": direction.X > threshold,
+	}
+	var commands = []PlayerSessionCommand {}
+	for _, method := range []string { "moveUp", "moveDown", "moveLeft", "moveRight" } {
+		commands = append(commands, PlayerSessionCommand {
+			Method: method,
+			Params: CommandParams {
+				"value": moving[method],
+			},
+		})
+	}
+	return commands
+}
+
+/**
+ * <game>.botShoot:
+ * The function to get the shoot command toward the offset.
+ *
+ * @param {util.Vec2} offset																	- the offset from the bot to the target
+ *
+ * @return {PlayerSessionCommand}
+ */
+func botShoot (offset util.Vec2) PlayerSessionCommand {
+	return PlayerSessionCommand {
+		Method: "shoot",
+		Params: CommandParams {
+			"angle": math.Atan2(offset.Y, offset.X) / (2 * math.Pi) * 360,
+		},
+	}
+}
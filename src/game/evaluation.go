@@ -0,0 +1,108 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+)
+
+/**
+ * EvaluationRule:
+ * The struct of the stat upgrade rule loaded from the config file.
+ *
+ * @property {int} Cost																				- the skill points spent in one upgrade
+ * @property {map[string]int} MaxLevel												- the max level of every upgradable stat
+ */
+type EvaluationRule struct {
+	Cost int
+	MaxLevel map[string]int
+}
+
+// keep the evaluation rule loaded once for all games
+var evaluationRule EvaluationRule
+var evaluationRuleOnce sync.Once
+
+/**
+ * <game>.GetEvaluationRule:
+ * The function to get the evaluation rule, it reads the config file at the first call.
+ *
+ * @return {EvaluationRule}
+ */
+func GetEvaluationRule () EvaluationRule {
+	evaluationRuleOnce.Do(func () {
+		evaluationRule = EvaluationRule {
+			Cost: 1,
+			MaxLevel: map[string]int {},
+		}
+		jsonFile, err := os.Open("./src/config/evaluation.json")
+		if (err != nil) {
+			log.Print(err)
+			return
+		}
+		defer jsonFile.Close()
+		byteValue, _ := ioutil.ReadAll(jsonFile)
+		if err = json.Unmarshal(byteValue, &evaluationRule); err != nil {
+			log.Print(err)
+		}
+	})
+	return evaluationRule
+}
+
+/**
+ * <*PlayerStatus>.field:
+ * The function in PlayerStatus to get the reference of the stat by name.
+ *
+ * @param {string} name																				- the name of the stat
+ *
+ * @return {*int}																							- nil if the stat is unknown
+ */
+func (s *PlayerStatus) field (name string) *int {
+	switch name {
+		case "MaxHP":
+			return &s.MaxHP
+		case "HPRegeneration":
+			return &s.HPRegeneration
+		case "MoveSpeed":
+			return &s.MoveSpeed
+		case "BulletSpeed":
+			return &s.BulletSpeed
+		case "BulletPenetration":
+			return &s.BulletPenetration
+		case "BulletReload":
+			return &s.BulletReload
+		case "BulletDamage":
+			return &s.BulletDamage
+		case "BodyDamage":
+			return &s.BodyDamage
+	}
+	return nil
+}
+
+/**
+ * <*Player>.Evaluate:
+ * The function in Player to spend skill points on upgrading one stat.
+ *
+ * @param {string} name																				- the name of the stat
+ *
+ * @return {int, int, error}																	- the origin level, the upgraded level and the rejection
+ */
+func (p *Player) Evaluate (name string) (int, int, error) {
+	var rule = GetEvaluationRule()
+	var stat = p.Status.field(name)
+	max_level, has_rule := rule.MaxLevel[name]
+	if (stat == nil) || (!has_rule) {
+		return 0, 0, errors.New("Unknown stat " + name + "!")
+	}
+	if (*stat >= max_level) {
+		return *stat, *stat, errors.New("The stat " + name + " meet the maximum!")
+	}
+	if (p.Attr.SkillPoint < rule.Cost) {
+		return *stat, *stat, errors.New("You do not have enough skill point!")
+	}
+	p.Attr.SkillPoint -= rule.Cost
+	*stat++
+	return *stat - 1, *stat, nil
+}
@@ -1,19 +1,20 @@
 package game
 
 import (
+	"errors"
 	"github.com/gorilla/websocket"
 	"log"
 	"math"
-	"sort"
 	"time"
-	"math/rand"
 	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
 	"sync"
 )
 
-// define the game friction
+// define the default game friction
 const friction = 0.97
 const ratio = 1.5
+// define the max number of overdue ticks to catch up in one burst
+const maxCatchUpTicks = 5
 
 /**
  * Game:
@@ -25,7 +26,20 @@ const ratio = 1.5
  * @property {chan *PlayerSessions} JoinChannel								- the channel of joining player
  * @property {*util.Size} Field																- the field information of the game
  * @property {float64} Framerate															- the framerate of the game
+ * @property {uint64} Tick																		- the number of the current simulation tick
+ * @property {RoomConfig} Config															- the settings of the game room
  * @property {*GameLogger} Logger															- the logger of the game
+ * @property {bool} Persistent																- whether the room is kept open when it is empty
+ * @property {time.Time} CreatedAt														- the time when the room is opened
+ * @property {time.Time} emptySince														- the time when the last member left, zero if not empty
+ * @property {chan bool} quit																	- the channel closed to stop all routines of the game
+ * @property {sync.Once} closeOnce														- the guard to close the game only once
+ * @property {sync.WaitGroup} routines												- the wait group of the running routines of the game
+ * @property {map[string]*suspendedSession} suspended					- the sessions lost connection and waiting for resuming by token
+ * @property {uint32} nextNetId																- the last assigned network id of the game objects
+ * @property {[]positionFrame} history												- the diep positions of the recent ticks for the lag compensation
+ * @property {int} nextBotId																	- the last number used in the bot names
+ * @property {GameMode} Mode																	- the rules of the game mode
  */
  type Game struct {
 	Name string
@@ -34,8 +48,21 @@ const ratio = 1.5
 	JoinChannel chan *PlayerSession
 	Field *util.Size
 	Framerate float64
+	Tick uint64
+	Config RoomConfig
 	ControlLock sync.Mutex
 	Logger *GameLogger
+	Persistent bool
+	CreatedAt time.Time
+	emptySince time.Time
+	quit chan bool
+	closeOnce sync.Once
+	routines sync.WaitGroup
+	suspended map[string]*suspendedSession
+	nextNetId uint32
+	history []positionFrame
+	nextBotId int
+	Mode GameMode
 }
 
 /**
@@ -43,12 +70,11 @@ const ratio = 1.5
  * The function to new a game instance.
  *
  * @param {string} name																				- the unique name of the game room
- * @param {float64} width																			- the width of the game field
- * @param {float64} height																		- the height of the game field
+ * @param {RoomConfig} config																	- the settings of the game room
  *
  * @return {*Game}
  */
-func NewGame(name string, width, height float64) *Game {
+func NewGame(name string, config RoomConfig) *Game {
 	game := Game {
 		Name: name,
 		Sessions: []*PlayerSession {},
@@ -59,16 +85,34 @@ func NewGame(name string, width, height float64) *Game {
 			Traps: []*Trap {},
 		},
 		Field: &util.Size {
-			W: width,
-			H: height,
+			W: config.Field.W,
+			H: config.Field.H,
 		},
-		Framerate: 50.0,
+		Framerate: config.TickRate,
+		Config: config,
 		Logger: NewLogger(name),
+		CreatedAt: time.Now(),
+		emptySince: time.Now(),
+		quit: make(chan bool),
+		suspended: map[string]*suspendedSession {},
+		history: newPositionHistory(config),
 	}
+	// the config is validated, fall back to the free-for-all mode anyway
+	mode, err := NewGameMode(config)
+	if (err != nil) {
+		log.Print(err)
+		mode = &FFA {}
+	}
+	game.Mode = mode
+	game.MapInfo.Index = NewMapIndex(game.Field)
+	game.routines.Add(5)
 	go game.runListen()
 	go game.loop()
+	go game.runBots()
 	// generate the stuff randomly
 	go game.generateStuff()
+	// place the traps randomly
+	go game.generateTrap()
 	return &game
 }
 
@@ -90,13 +134,16 @@ func NewGame(name string, width, height float64) *Game {
 		Game: game,
 		MBus: make (chan bool, 1),
 		Alive: true,
+		ResumeToken: newResumeToken(),
+		Codec: JSONCodec {},
+		outbound: make (chan outboundMessage, outboundQueueSize),
+		wake: make (chan bool, 1),
+		closed: make (chan bool),
+		writerDone: make (chan bool),
 	}
 	// log the connection
 	game.Logger.establishConnection(ws.RemoteAddr().String(), player.Attr.Name, game.Name, int(len(game.Sessions)) + 1)
-	// parallel execute receiver, loop and ping function
-	go ps.receiver()
-	go ps.loop()
-	go ps.ping()
+	// the receiver, ping and writer function start when the game accepts the session
 	return &ps
 }
 
@@ -113,22 +160,11 @@ func NewPlayer (name string) *Player {
 	var new_player = Player {
 		GameObject: GameObject {
 			Id: uuid,
-			Position: util.Point {
-				X: rand.Float64() * 1023,
-				Y: rand.Float64() * 1023,
-			},
+			Position: util.Vec2 {},
 			Mass: 1.0,
 			Radius: 50.0,
-			Velocity: util.VelocityFormat {
-				X: 0.0,
-				Y: 0.0,
-			},
-			Acceleration: util.AccelerationFormat {
-				Up: 0.0,
-				Down: 0.0,
-				Left: 0.0,
-				Right: 0.0,
-			},
+			Velocity: util.Vec2 {},
+			Acceleration: util.Vec2 {},
 		},
 		Attr: PlayerAttribute {
 			Name: name,
@@ -136,11 +172,13 @@ func NewPlayer (name string) *Player {
 			Score: 0,
 			Level: 1,
 			EXP: 0,
+			SkillPoint: 0,
+			Class: defaultTankClass,
 			HP: 100,
 			ShootCD: 0,
 		},
 		Status: PlayerStatus {
-			MaxHP: 100,
+			MaxHP: 1,
 			HPRegeneration: 1,
 			MoveSpeed: 1,
 			BulletSpeed: 1,
@@ -170,26 +208,91 @@ func NewPlayer (name string) *Player {
  * @return {nil}
  */
 func (g *Game) runListen () {
+	defer g.routines.Done()
 	for {
+		var p_sess *PlayerSession
 		// get the current join player session from channel
-		p_sess := <- g.JoinChannel
+		select {
+			case p_sess = <- g.JoinChannel:
+			case <- g.quit:
+				return
+		}
 		g.ControlLock.Lock()
+		g.emptySince = time.Time {}
 		// append the player session to Sessions
 		g.Sessions = append(g.Sessions, p_sess)
+		// put the player diep on the field
+		g.addPlayer(p_sess.Player)
+		// give the seat of a bot to the player
+		g.balanceBots()
 		g.ControlLock.Unlock()
+		p_sess.start()
 		log.Printf("Player %s has joined\n", p_sess.Player.Attr.Name)
 	}
 }
 
+/**
+ * <*Game>.addPlayer:
+ * The function in Game to let the game mode prepare the joining player and put its diep on the field.
+ * The caller should hold the game lock.
+ *
+ * @param {*Player} player																		- the joining player
+ *
+ * @return {nil}
+ */
+func (g *Game) addPlayer (player *Player) {
+	g.Mode.OnJoin(g, player)
+	g.addDiep(player)
+}
+
+/**
+ * <*Game>.removePlayer:
+ * The function in Game to take the diep of the leaving player off the field and tell the game mode.
+ * The caller should hold the game lock.
+ *
+ * @param {*Player} player																		- the leaving player
+ *
+ * @return {nil}
+ */
+func (g *Game) removePlayer (player *Player) {
+	g.removeDiep(player.GameObject.Id)
+	g.Mode.OnLeave(g, player)
+}
+
+/**
+ * <*Game>.addDiep:
+ * The function in Game to put the diep of the player on the field at a safe spawn point of the game mode.
+ * The caller should hold the game lock.
+ *
+ * @param {*Player} player																		- the player of the diep
+ *
+ * @return {nil}
+ */
+func (g *Game) addDiep (player *Player) {
+	player.Position = g.safeSpawnPoint(player)
+	g.MapInfo.Dieps = append(g.MapInfo.Dieps, &Diep {
+		Name: player.Attr.Name,
+		GameObject: &player.GameObject,
+		Player: player,
+	})
+}
+
 /**
  * <*Game>.JoinPlayer:
  * The function in Game to send session to the channel.
  *
- * @return {string}
+ * @param {*PlayerSession} session														- the joining player session
+ *
+ * @return {error}
  */
- func (g *Game) JoinPlayer (session *PlayerSession) {
-	// add the player session to channel
-	g.JoinChannel <- session
+ func (g *Game) JoinPlayer (session *PlayerSession) error {
+	// add the player session to channel unless the game is closed
+	select {
+		case g.JoinChannel <- session:
+			return nil
+		case <- g.quit:
+			return errors.New("The game room " + g.Name + " is closed!")
+	}
 }
 
 /**
@@ -203,13 +306,142 @@ func (g *Game) runListen () {
  func (g *Game) Disconnect (player_name string) {
 	 // log the disconnection
 	g.Logger.closeConnection(player_name, g.Name, int(len(g.Sessions)) - 1)
-	// remove the player session from the game
-	var index int = sort.Search(len(g.Sessions), func (i int) bool {
-		return g.Sessions[i].Player.Attr.Name == player_name
+	// remove the player session and the diep from the game
+	g.removeSessions(func (ps *PlayerSession) bool {
+		return ps.Player.Attr.Name == player_name
 	})
+}
+
+/**
+ * <*Game>.removeSessions:
+ * The function in Game to remove the matched player sessions and their dieps from the game.
+ *
+ * @property {func(*PlayerSession) bool} match				- the function to match the target sessions
+ *
+ * @return {[]*PlayerSession}															- the removed sessions
+ */
+func (g *Game) removeSessions (match func(*PlayerSession) bool) []*PlayerSession {
+	var removed = []*PlayerSession {}
 	g.ControlLock.Lock()
-	g.Sessions = append(g.Sessions[:index], g.Sessions[index+1:]...)
+	var sessions = g.Sessions[:0]
+	for _, ps := range g.Sessions {
+		if (match(ps)) {
+			removed = append(removed, ps)
+			g.removePlayer(ps.Player)
+		} else {
+			sessions = append(sessions, ps)
+		}
+	}
+	g.Sessions = sessions
+	// start counting the idle time when the last member left
+	if (g.humanCount() == 0 && len(removed) > 0) {
+		g.emptySince = time.Now()
+	}
 	g.ControlLock.Unlock()
+	return removed
+}
+
+/**
+ * <*Game>.IdleFor:
+ * The function in Game to get how long the game has been empty.
+ *
+ * @return {time.Duration}																			- zero if there is any member in the game, the bots are not counted
+ */
+func (g *Game) IdleFor () time.Duration {
+	g.ControlLock.Lock()
+	defer g.ControlLock.Unlock()
+	if (g.humanCount() > 0 || len(g.suspended) > 0 || g.emptySince.IsZero()) {
+		return 0
+	}
+	return time.Since(g.emptySince)
+}
+
+/**
+ * <*Game>.Close:
+ * The function in Game to stop all routines of the game, drop the remaining players and close the logger.
+ * It blocks until every routine has returned, and is safe to call more than once.
+ *
+ * @return {nil}
+ */
+func (g *Game) Close () {
+	g.closeOnce.Do(func () {
+		close(g.quit)
+		g.routines.Wait()
+		// drop the remaining players if the room is closed by force
+		for _, ps := range g.removeSessions(func (ps *PlayerSession) bool { return true }) {
+			ps.drop("roomClosed", CommandParams {
+				"room": g.Name,
+			})
+		}
+		g.Logger.close()
+	})
+}
+
+/**
+ * <*Game>.sleep:
+ * The function in Game to sleep for the duration unless the game is closed.
+ *
+ * @param {time.Duration} duration														- the duration to sleep
+ *
+ * @return {bool}																							- false if the game is closed
+ */
+func (g *Game) sleep (duration time.Duration) bool {
+	var timer = time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+		case <- timer.C:
+			return true
+		case <- g.quit:
+			return false
+	}
+}
+
+/**
+ * <*Game>.findSession:
+ * The function in Game to find the player session by the player game object id.
+ *
+ * @property {string} id								- the game object id of the player
+ *
+ * @return {*PlayerSession}
+ */
+func (g *Game) findSession (id string) *PlayerSession {
+	for _, ps := range g.Sessions {
+		if (ps.Player.GameObject.Id == id) {
+			return ps
+		}
+	}
+	return nil
+}
+
+/**
+ * <*Game>.CheckJoin:
+ * The function in Game to check if a new player can join, the players waiting for resuming keep their seats and names.
+ * The bots give their seats to the new player.
+ *
+ * @property {string} name							- the name of the new player
+ *
+ * @return {error}
+ */
+func (g *Game) CheckJoin (name string) error {
+	g.ControlLock.Lock()
+	defer g.ControlLock.Unlock()
+	if (g.humanCount() + len(g.suspended) >= g.Config.MaxMembers) {
+		return errors.New("The member of game room meet maximum")
+	}
+	if (!g.Mode.CanJoin(g)) {
+		return errors.New("The round of game room " + g.Name + " is running, please join later!")
+	}
+	for _, ps := range g.Sessions {
+		if (ps.Player.Attr.Name == name) {
+			return errors.New("Repeat player name!")
+		}
+	}
+	for _, suspended := range g.suspended {
+		if (suspended.session.Player.Attr.Name == name) {
+			return errors.New("Repeat player name!")
+		}
+	}
+	return nil
 }
 
 /**
@@ -219,12 +451,42 @@ func (g *Game) runListen () {
  * @return {nil}
  */
 func (g *Game) loop () {
+	defer g.routines.Done()
+	scheduler := NewTickScheduler(g.Framerate, maxCatchUpTicks)
+	var snapshot_interval uint64 = 1
+	if (g.Config.SnapshotInterval > 1) {
+		snapshot_interval = uint64(g.Config.SnapshotInterval)
+	}
+	// keep the interval at least one tick for the tick rates below 1
+	var bot_interval = uint64(math.Max(1, math.Round(g.Framerate)))
 	for {
-		time.Sleep(time.Duration(1000.0 / g.Framerate) * time.Millisecond)
+		// wait for the next fixed-timestep tick
+		skipped := scheduler.Wait()
+		select {
+			case <- g.quit:
+				return
+			default:
+		}
+		if (skipped > 0) {
+			g.Logger.skipTicks(scheduler.Tick, skipped)
+		}
 		g.ControlLock.Lock()
+		g.Tick = scheduler.Tick
+		// release the dieps not resumed in time
+		g.expireSuspended()
+		// replace the dead bots and keep the bot count every second
+		if (g.Tick % bot_interval == 0) {
+			g.balanceBots()
+		}
+		// apply the player inputs received since the last tick
+		g.applyInputs()
 		// update the player movement
 		g.updatePhysicItems()
-		g.ControlLock.Unlock()
+		// re-bucket the moved items and clear the collisions of the last tick
+		g.rebuildIndex()
+		// aim and shoot with the traps
+		g.updateTraps()
+		g.MapInfo.Collisions = g.MapInfo.Collisions[:0]
 		// detect player & player collision
 		g.detectDeipCollision()
 		// detect player & stuff collision
@@ -233,8 +495,25 @@ func (g *Game) loop () {
 		g.detectTrapCollision()
 		// detect player & bullet collision
 		g.detectBulletCollision()
+		// detect trap & bullet collision
+		g.detectTrapBulletCollision()
 		// deal all collision
 		g.dealWithCollisions()
+		// apply the rules of the game mode
+		g.Mode.OnTick(g)
+		if (g.Mode.IsFinished(g)) {
+			g.endRound()
+		}
+		// keep the diep positions for rewinding the later bullets
+		g.recordHistory()
+		// build the snapshots of the end-of-tick state for all players
+		var snapshots []sessionSnapshot
+		if (g.Tick % snapshot_interval == 0) {
+			g.rebuildIndex()
+			snapshots = g.buildSnapshots()
+		}
+		g.ControlLock.Unlock()
+		g.sendSnapshots(snapshots)
 	}
 }
 
@@ -245,82 +524,55 @@ func (g *Game) loop () {
  * @return {nil}
  */
 func (g *Game) updatePhysicItems() {
+	var field_min = util.Vec2 {}
+	var field_max = util.Vec2 { X: g.Field.W, Y: g.Field.H }
 	// update the player movement
 	for _, ps := range g.Sessions {
 		ps.ControlLock.Lock()
+		var object = &ps.Player.GameObject
+		var move_speed = float64(ps.Player.Status.MoveSpeed)
 		// update the player acceleration
-		var new_acceleration util.AccelerationFormat
-		if (ps.Moving.Up) {
-			new_acceleration.Up = math.Min(ps.Player.GameObject.Acceleration.Up + (float64(ps.Player.Status.MoveSpeed)) * friction / g.Framerate, float64(ps.Player.Status.MoveSpeed))
-		} else {
-			new_acceleration.Up = math.Max(ps.Player.GameObject.Acceleration.Up * friction, 0) / g.Framerate
-		}
-		if (ps.Moving.Down) {
-			new_acceleration.Down = math.Min(ps.Player.GameObject.Acceleration.Down + (float64(ps.Player.Status.MoveSpeed)) * friction / g.Framerate, float64(ps.Player.Status.MoveSpeed))
-		} else {
-			new_acceleration.Down = math.Max(ps.Player.GameObject.Acceleration.Down * friction, 0) / g.Framerate
-		}
-		if (ps.Moving.Left) {
-			new_acceleration.Left = math.Min(ps.Player.GameObject.Acceleration.Left + (float64(ps.Player.Status.MoveSpeed)) * friction / g.Framerate, float64(ps.Player.Status.MoveSpeed))
+		var direction = ps.Moving.Vector()
+		if (direction.Length() > 0) {
+			object.Acceleration = object.Acceleration.Add(direction.Scale(move_speed * g.Config.Friction / g.Framerate)).ClampLength(move_speed)
 		} else {
-			new_acceleration.Left = math.Max(ps.Player.GameObject.Acceleration.Left * friction, 0) / g.Framerate
+			object.Acceleration = object.Acceleration.Scale(g.Config.Friction / g.Framerate)
 		}
-		if (ps.Moving.Right) {
-			new_acceleration.Right = math.Min(ps.Player.GameObject.Acceleration.Right + (float64(ps.Player.Status.MoveSpeed)) * friction / g.Framerate, float64(ps.Player.Status.MoveSpeed))
-		} else {
-			new_acceleration.Right = math.Max(ps.Player.GameObject.Acceleration.Right * friction, 0) / g.Framerate
-		}
-		ps.Player.GameObject.Acceleration = new_acceleration
 		// update the player velocity
-		ps.Player.GameObject.Velocity.X = math.Max(math.Min(ps.Player.GameObject.Velocity.X - ps.Player.GameObject.Acceleration.Left +
-			ps.Player.GameObject.Acceleration.Right, float64(ps.Player.Status.MoveSpeed + 10) / ratio), float64(ps.Player.Status.MoveSpeed + 10) * (-1.0) / ratio) * friction
-		ps.Player.GameObject.Velocity.Y = math.Max(math.Min(ps.Player.GameObject.Velocity.Y - ps.Player.GameObject.Acceleration.Up +
-				ps.Player.GameObject.Acceleration.Down, float64(ps.Player.Status.MoveSpeed + 10) / ratio), float64(ps.Player.Status.MoveSpeed + 10) * (-1.0) / ratio) * friction
-
+		object.Velocity = object.Velocity.Add(object.Acceleration).ClampLength((move_speed + 10) / ratio).Scale(g.Config.Friction)
 		// update the player location
-		ps.Player.GameObject.Position.X = math.Max(math.Min(ps.Player.GameObject.Position.X + ps.Player.GameObject.Velocity.X / g.Framerate, g.Field.W), 0)
-		ps.Player.GameObject.Position.Y = math.Max(math.Min(ps.Player.GameObject.Position.Y + ps.Player.GameObject.Velocity.Y / g.Framerate, g.Field.H), 0)
+		object.Position = object.Position.Add(object.Velocity.Scale(1 / g.Framerate)).Clamp(field_min, field_max)
 		
 		// update the player shoot CD time
-		ps.Player.Attr.ShootCD = math.Max(ps.Player.Attr.ShootCD - 1, 0)
+		ps.Player.reloadBarrels()
 
 		ps.ControlLock.Unlock()
 	}
 	// update the bullet movement
-	for i, bullet := range g.MapInfo.Bullets {
-		bullet.GameObject.Position.X = math.Max(math.Min(bullet.GameObject.Position.X + bullet.GameObject.Velocity.X / g.Framerate, g.Field.W), 0)
-		bullet.GameObject.Position.Y = math.Max(math.Min(bullet.GameObject.Position.Y + bullet.GameObject.Velocity.Y / g.Framerate, g.Field.H), 0)
-		// if the bullet collide with wall
-		if (bullet.GameObject.Position.X >= g.Field.W) || (bullet.GameObject.Position.X <= 0) ||
-			(bullet.GameObject.Position.Y >= g.Field.H) || (bullet.GameObject.Position.Y <= 0) {
-			// remove the bullet
-			g.MapInfo.Bullets = append(g.MapInfo.Bullets[:i], g.MapInfo.Bullets[i+1:]...)
-		}
+	var bullets = g.MapInfo.Bullets[:0]
+	for _, bullet := range g.MapInfo.Bullets {
+		bullet.GameObject.Position = bullet.GameObject.Position.Add(bullet.GameObject.Velocity.Scale(1 / g.Framerate))
 		// count for the bullet existence
 		bullet.Existence--;
-		if (bullet.Existence <= 0) {
-			// remove the bullet
-			g.MapInfo.Bullets = append(g.MapInfo.Bullets[:i], g.MapInfo.Bullets[i+1:]...)
+		// remove the bullet if it collides with wall or runs out of existence
+		if (bullet.GameObject.Position.X >= g.Field.W) || (bullet.GameObject.Position.X <= 0) ||
+			(bullet.GameObject.Position.Y >= g.Field.H) || (bullet.GameObject.Position.Y <= 0) ||
+			(bullet.Existence <= 0) {
+			bullet.Existence = 0
+			continue
 		}
+		bullets = append(bullets, bullet)
 	}
+	g.MapInfo.Bullets = bullets
 	// update the stuff movement
 	for _, stuff := range g.MapInfo.Stuffs {
+		var object = &stuff.GameObject
 		// update the stuff acceleration
-		var new_acceleration util.AccelerationFormat
-		new_acceleration.Up = math.Max(stuff.GameObject.Acceleration.Up * friction, 0) / g.Framerate
-		new_acceleration.Down = math.Max(stuff.GameObject.Acceleration.Down * friction, 0) / g.Framerate
-		new_acceleration.Left = math.Max(stuff.GameObject.Acceleration.Left * friction, 0) / g.Framerate
-		new_acceleration.Right = math.Max(stuff.GameObject.Acceleration.Right * friction, 0) / g.Framerate
-		stuff.GameObject.Acceleration = new_acceleration
-		// update the player velocity
-		stuff.GameObject.Velocity.X = (stuff.GameObject.Velocity.X - stuff.GameObject.Acceleration.Left +
-			stuff.GameObject.Acceleration.Right) * friction
-		stuff.GameObject.Velocity.Y = (stuff.GameObject.Velocity.Y - stuff.GameObject.Acceleration.Up +
-				stuff.GameObject.Acceleration.Down) * friction
-
-		// update the player location
-		stuff.GameObject.Position.X = math.Max(math.Min(stuff.GameObject.Position.X + stuff.GameObject.Velocity.X / g.Framerate, g.Field.W), 0)
-		stuff.GameObject.Position.Y = math.Max(math.Min(stuff.GameObject.Position.Y + stuff.GameObject.Velocity.Y / g.Framerate, g.Field.H), 0)
+		object.Acceleration = object.Acceleration.Scale(g.Config.Friction / g.Framerate)
+		// update the stuff velocity
+		object.Velocity = object.Velocity.Add(object.Acceleration).Scale(g.Config.Friction)
+		// update the stuff location
+		object.Position = object.Position.Add(object.Velocity.Scale(1 / g.Framerate)).Clamp(field_min, field_max)
 	}
 }
 
@@ -332,28 +584,13 @@ func (g *Game) updatePhysicItems() {
  */
 func (g *Game) detectDeipCollision() {
 	for _, diep_a := range g.MapInfo.Dieps {
-		for _, diep_b := range g.MapInfo.Dieps {
-			if (diep_a.GameObject.Id == diep_b.GameObject.Id) {
+		for _, object := range g.MapInfo.Index.Dieps.QueryCircle(diep_a.Position, diep_a.Radius) {
+			diep_b := object.(*Diep)
+			// prevent the collision detect twice by keeping the pair in one order only
+			if (diep_a.GameObject.Id >= diep_b.GameObject.Id) {
 				continue
 			}
-			var diff_x = math.Abs(diep_a.GameObject.Position.X - diep_b.GameObject.Position.X)
-			var diff_y = math.Abs(diep_a.GameObject.Position.Y - diep_b.GameObject.Position.Y)
-			var check = false
-			if (math.Sqrt(math.Pow(diff_x, 2) + math.Pow(diff_y, 2)) <= diep_a.Radius + diep_b.Radius) {
-				check = true
-			}
-			
-			// collision happend, then add the acceleration in opposite direction
-			if (check) {
-				pair_exist := sort.Search(len(g.MapInfo.Collisions), func (i int) bool {
-					targetId := GetObjectId(g.MapInfo.Collisions[i].object_b)
-					return (g.MapInfo.Collisions[i].object_a.GameObject.Id == diep_a.GameObject.Id && targetId == diep_b.GameObject.Id) || 
-						(g.MapInfo.Collisions[i].object_a.GameObject.Id == diep_b.GameObject.Id && targetId == diep_a.GameObject.Id)
-				})
-				if (pair_exist >= len(g.MapInfo.Collisions)) {
-					continue
-				}
-				// prevent the collision detect twice by add the pair to the list unrepeatly
+			if (diep_a.GameObject.Overlap(diep_b.GameObject)) {
 				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
 					object_a: diep_a,
 					object_b: diep_b,
@@ -371,25 +608,9 @@ func (g *Game) detectDeipCollision() {
  */
 func (g *Game) detectStuffCollision () {
 	for _, diep := range g.MapInfo.Dieps {
-		for _, stuff := range g.MapInfo.Stuffs {
-			var diff_x = math.Abs(diep.GameObject.Position.X - stuff.GameObject.Position.X)
-			var diff_y = math.Abs(diep.GameObject.Position.Y - stuff.GameObject.Position.Y)
-			var check = false
-			if (math.Sqrt(math.Pow(diff_x, 2) + math.Pow(diff_y, 2)) <= diep.Radius + stuff.Radius) {
-				check = true
-			}
-			
-			// collision happend, then add the acceleration in opposite direction
-			if (check) {
-				pair_exist := sort.Search(len(g.MapInfo.Collisions), func (i int) bool {
-					targetId := GetObjectId(g.MapInfo.Collisions[i].object_b)
-					return (g.MapInfo.Collisions[i].object_a.GameObject.Id == diep.Id && targetId == stuff.Id) || 
-						(g.MapInfo.Collisions[i].object_a.GameObject.Id == stuff.Id && targetId == diep.Id)
-				})
-				if (pair_exist >= len(g.MapInfo.Collisions)) {
-					continue
-				}
-				// prevent the collision detect twice by add the pair to the list unrepeatly
+		for _, object := range g.MapInfo.Index.Stuffs.QueryCircle(diep.Position, diep.Radius) {
+			stuff := object.(*Stuff)
+			if (diep.GameObject.Overlap(&stuff.GameObject)) {
 				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
 					object_a: diep,
 					object_b: stuff,
@@ -407,26 +628,9 @@ func (g *Game) detectStuffCollision () {
  */
 func (g *Game) detectTrapCollision () {
 	for _, diep := range g.MapInfo.Dieps {
-		for _, trap := range g.MapInfo.Traps {
-			var diff_x = math.Abs(diep.GameObject.Position.X - trap.GameObject.Position.Y)
-			var diff_y = math.Abs(diep.GameObject.Position.X - trap.GameObject.Position.Y)
-			var check = false
-			if (math.Sqrt(math.Pow(diff_x, 2) + math.Pow(diff_y, 2)) <= diep.Radius + trap.Radius) {
-				check = true
-			}
-			
-			// collision happend, then add the acceleration in opposite direction
-			if (check) {
-
-				pair_exist := sort.Search(len(g.MapInfo.Collisions), func (i int) bool {
-					targetId := GetObjectId(g.MapInfo.Collisions[i].object_b)
-					return (g.MapInfo.Collisions[i].object_a.GameObject.Id == diep.Id && targetId == trap.Id) || 
-						(g.MapInfo.Collisions[i].object_a.GameObject.Id == trap.Id && targetId == diep.Id)
-				})
-				if (pair_exist >= len(g.MapInfo.Collisions)) {
-					continue
-				}
-				// prevent the collision detect twice by add the pair to the list unrepeatly
+		for _, object := range g.MapInfo.Index.Traps.QueryCircle(diep.Position, diep.Radius) {
+			trap := object.(*Trap)
+			if (diep.GameObject.Overlap(&trap.GameObject)) {
 				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
 					object_a: diep,
 					object_b: trap,
@@ -439,31 +643,18 @@ func (g *Game) detectTrapCollision () {
 /**
  * <*Game>.detectBulletCollision:
  * The function in Game to detect if there is collision between diep and bullet.
+ * In every tick of its life, the bullet is tested against the diep at the position its owner sees, rewound by the owner latency.
  *
  * @return {nil}
  */
 func (g *Game) detectBulletCollision () {
 	for _, diep := range g.MapInfo.Dieps {
-		for _, bullet := range g.MapInfo.Bullets {
-			var diff_x = math.Abs(diep.GameObject.Position.X - bullet.GameObject.Position.Y)
-			var diff_y = math.Abs(diep.GameObject.Position.X - bullet.GameObject.Position.Y)
-			var check = false
-			if (math.Sqrt(math.Pow(diff_x, 2) + math.Pow(diff_y, 2)) <= diep.Radius + bullet.Radius) {
-				check = true
-			}
-			
-			// collision happend, then add the acceleration in opposite direction
-			if (check) {
-
-				pair_exist := sort.Search(len(g.MapInfo.Collisions), func (i int) bool {
-					targetId := GetObjectId(g.MapInfo.Collisions[i].object_b)
-					return (g.MapInfo.Collisions[i].object_a.GameObject.Id == diep.Id && targetId == bullet.Id) || 
-						(g.MapInfo.Collisions[i].object_a.GameObject.Id == bullet.Id && targetId == diep.Id)
-				})
-				if (pair_exist >= len(g.MapInfo.Collisions)) {
-					continue
-				}
-				// prevent the collision detect twice by add the pair to the list unrepeatly
+		// also cover the bullets tested against the past positions
+		var reach = g.rewindReach(diep)
+		for _, object := range g.MapInfo.Index.Bullets.QueryCircle(diep.Position, diep.Radius + reach) {
+			bullet := object.(*Bullet)
+			var position = g.rewoundPosition(diep, bullet.Rewind)
+			if (position.Sub(bullet.Position).Length() <= diep.Radius + bullet.Radius) {
 				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
 					object_a: diep,
 					object_b: bullet,
@@ -481,207 +672,140 @@ func (g *Game) detectBulletCollision () {
  */
 func (g *Game) dealWithCollisions () {
 	for _, collision := range g.MapInfo.Collisions {
-		// object_a must bee diep, then just get the player_a session
-		player_session_a_index := sort.Search(len(g.Sessions), func (i int) bool {
-			return g.Sessions[i].Player.GameObject.Id == collision.object_a.GameObject.Id
-		})
-		var player_session_a = g.Sessions[player_session_a_index]
-
-		switch collision.object_b.(type) {
+		switch object_a := collision.object_a.(type) {
 			case *Diep:
-				target := collision.object_b.(*Diep)
-				player_session_b_index := sort.Search(len(g.Sessions), func (i int) bool {
-					return g.Sessions[i].Player.GameObject.Id == target.GameObject.Id
-				})
-				var player_session_b = g.Sessions[player_session_b_index]
-				// update the acceleration of two player
-				var new_acceleration_a = util.AccelerationFormat {
-					Up: 0.0,
-					Down: 0.0,
-					Left: 0.0,
-					Right: 0.0,
-				}
-				new_acceleration_a.Up = player_session_a.Player.GameObject.Acceleration.Up + player_session_b.Player.GameObject.Acceleration.Up
-				new_acceleration_a.Down = player_session_a.Player.GameObject.Acceleration.Down + player_session_b.Player.GameObject.Acceleration.Down
-				new_acceleration_a.Left = player_session_a.Player.GameObject.Acceleration.Left + player_session_b.Player.GameObject.Acceleration.Left
-				new_acceleration_a.Right = player_session_a.Player.GameObject.Acceleration.Right + player_session_b.Player.GameObject.Acceleration.Right
-
-				var new_acceleration_b = util.AccelerationFormat {
-					Up: 0.0,
-					Down: 0.0,
-					Left: 0.0,
-					Right: 0.0,
-				}
-				new_acceleration_b.Up = player_session_b.Player.GameObject.Acceleration.Up + player_session_a.Player.GameObject.Acceleration.Up
-				new_acceleration_b.Down = player_session_b.Player.GameObject.Acceleration.Down + player_session_a.Player.GameObject.Acceleration.Down
-				new_acceleration_b.Left = player_session_b.Player.GameObject.Acceleration.Left + player_session_a.Player.GameObject.Acceleration.Left
-				new_acceleration_b.Right = player_session_b.Player.GameObject.Acceleration.Right + player_session_a.Player.GameObject.Acceleration.Right
-				
-				player_session_a.Player.GameObject.Acceleration = new_acceleration_a
-				player_session_b.Player.GameObject.Acceleration = new_acceleration_b
-				
-				// give the collision damage
-				player_session_a.Player.Attr.HP -= float64(player_session_b.Player.Status.BodyDamage) * 5.0
-				player_session_b.Player.Attr.HP -= float64(player_session_a.Player.Status.BodyDamage) * 5.0
-				// deal with the dead
-				if (player_session_a.Player.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(player_session_a.Player.GameObject.Id, player_session_b.Player.GameObject.Id)
-					// send the dead message first
-					player_session_a.sendClientCommand(PlayerSessionCommand {
-						Method: "playerDead",
-						Params: CommandParams {},
-					})
-					player_session_a.ControlLock.Lock()
-					player_session_a.Alive = false
-					player_session_a.ControlLock.Unlock()
-				}
-				if (player_session_b.Player.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(player_session_b.Player.GameObject.Id, player_session_a.Player.GameObject.Id)
-					// send the dead message first
-					player_session_b.sendClientCommand(PlayerSessionCommand {
-						Method: "playerDead",
-						Params: CommandParams {},
-					})
-					player_session_b.ControlLock.Lock()
-					player_session_b.Alive = false
-					player_session_b.ControlLock.Unlock()
-				}
-				break;
-			case *Stuff:
-				target := collision.object_b.(*Stuff)
-				stuff_index := sort.Search(len(g.MapInfo.Stuffs), func (i int) bool {
-					return g.MapInfo.Stuffs[i].GameObject.Id == target.GameObject.Id
-				})
-				var stuff = g.MapInfo.Stuffs[stuff_index]
-				// update the acceleration of two player
-				var new_acceleration_a = util.AccelerationFormat {
-					Up: 0.0,
-					Down: 0.0,
-					Left: 0.0,
-					Right: 0.0,
-				}
-				new_acceleration_a.Up = player_session_a.Player.GameObject.Acceleration.Up + stuff.Acceleration.Up
-				new_acceleration_a.Down = player_session_a.Player.GameObject.Acceleration.Down + stuff.Acceleration.Down
-				new_acceleration_a.Left = player_session_a.Player.GameObject.Acceleration.Left + stuff.Acceleration.Left
-				new_acceleration_a.Right = player_session_a.Player.GameObject.Acceleration.Right + stuff.Acceleration.Right
-
-				var new_acceleration_s = util.AccelerationFormat {
-					Up: 0.0,
-					Down: 0.0,
-					Left: 0.0,
-					Right: 0.0,
-				}
-				new_acceleration_s.Up = stuff.Acceleration.Up + player_session_a.Player.GameObject.Acceleration.Up
-				new_acceleration_s.Down = stuff.Acceleration.Down + player_session_a.Player.GameObject.Acceleration.Down
-				new_acceleration_s.Left = stuff.Acceleration.Left + player_session_a.Player.GameObject.Acceleration.Left
-				new_acceleration_s.Right = stuff.Acceleration.Right + player_session_a.Player.GameObject.Acceleration.Right
-				
-				player_session_a.Player.GameObject.Acceleration = new_acceleration_a
-				stuff.Acceleration = new_acceleration_s
-				
-				// give the collision damage
-				player_session_a.Player.Attr.HP -= float64(stuff.Attr.BodyDamage) * 5.0
-				stuff.Attr.HP -= float64(player_session_a.Player.Status.BodyDamage) * 5.0
-				// deal with the dead
-				if (player_session_a.Player.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(player_session_a.Player.GameObject.Id, stuff.GameObject.Id)
-					// send the dead message first
-					player_session_a.sendClientCommand(PlayerSessionCommand {
-						Method: "playerDead",
-						Params: CommandParams {},
-					})
-					player_session_a.ControlLock.Lock()
-					player_session_a.Alive = false
-					player_session_a.ControlLock.Unlock()
-				}
-				if (stuff.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(stuff.GameObject.Id, player_session_a.Player.GameObject.Id)
-					player_session_a.Player.GainEXP(stuff.Attr.EXP)
-					// remove the stuff
-					g.MapInfo.Stuffs = append(g.MapInfo.Stuffs[:stuff_index], g.MapInfo.Stuffs[stuff_index+1:]...)
-				}
+				g.dealWithDiepCollision(object_a, collision.object_b)
 				break;
 			case *Trap:
-				target := collision.object_b.(*Trap)
-				trap_index := sort.Search(len(g.MapInfo.Traps), func (i int) bool {
-					return g.MapInfo.Traps[i].GameObject.Id == target.GameObject.Id
-				})
-				var trap = g.MapInfo.Traps[trap_index]
-				// update the acceleration of two player
-				var new_acceleration_a = util.AccelerationFormat {
-					Up: 0.0,
-					Down: 0.0,
-					Left: 0.0,
-					Right: 0.0,
-				}
-				if (player_session_a.Player.GameObject.Acceleration.Left > 0.0) || (player_session_a.Player.GameObject.Acceleration.Right > 0.0) {
-					new_acceleration_a.Up = player_session_a.Player.GameObject.Acceleration.Up
-					new_acceleration_a.Down = player_session_a.Player.GameObject.Acceleration.Down
-					new_acceleration_a.Left = player_session_a.Player.GameObject.Acceleration.Left * (-1.0)
-					new_acceleration_a.Right = player_session_a.Player.GameObject.Acceleration.Right * (-1.0)
-				} else {
-					new_acceleration_a.Up = player_session_a.Player.GameObject.Acceleration.Up * (-1.0)
-					new_acceleration_a.Down = player_session_a.Player.GameObject.Acceleration.Down * (-1.0)
-					new_acceleration_a.Left = player_session_a.Player.GameObject.Acceleration.Left
-					new_acceleration_a.Right = player_session_a.Player.GameObject.Acceleration.Right
-				}
+				g.dealWithTrapCollision(object_a, collision.object_b)
+				break;
+		}
+	}
+}
+
+/**
+ * <*Game>.dealWithDiepCollision:
+ * The function in Game to apply the collision effect between diep and another item.
+ *
+ * @param {*Diep} diep																				- the diep in the collision
+ * @param {GameObjectInterface} target												- the collider
+ *
+ * @return {nil}
+ */
+func (g *Game) dealWithDiepCollision (diep *Diep, target GameObjectInterface) {
+	// the diep must belong to a player, then just get the player_a session
+	var player_session_a = g.findSession(diep.GameObject.Id)
+	// skip the collision if the player has left or dead in this tick
+	if (player_session_a == nil) || (!player_session_a.Playing()) {
+		return
+	}
 
-				player_session_a.Player.GameObject.Acceleration = new_acceleration_a
-				
-				// give the collision damage
-				player_session_a.Player.Attr.HP -= float64(trap.Attr.BodyDamage) * 5.0
-				// deal with the dead
+	switch target.(type) {
+		case *Diep:
+			var player_session_b = g.findSession(target.(*Diep).GameObject.Id)
+			if (player_session_b == nil) || (!player_session_b.Playing()) {
+				return
+			}
+			// separate the two circles and exchange the momentum by mass
+			g.resolveCollision(player_session_a.Player.GetGameObject(), player_session_b.Player.GetGameObject())
+			
+			// give the collision damage decided by the game mode
+			player_session_a.Player.Attr.HP -= g.Mode.OnDamage(g, player_session_a.Player, player_session_b.Player.Team, float64(player_session_b.Player.Status.BodyDamage) * 5.0)
+			player_session_b.Player.Attr.HP -= g.Mode.OnDamage(g, player_session_b.Player, player_session_a.Player.Team, float64(player_session_a.Player.Status.BodyDamage) * 5.0)
+			// deal with the dead
+			if (player_session_a.Player.Attr.HP <= 0) {
+				g.killPlayer(player_session_a, player_session_b.Player.GameObject.Id, "")
+			}
+			if (player_session_b.Player.Attr.HP <= 0) {
+				g.killPlayer(player_session_b, player_session_a.Player.GameObject.Id, "")
+			}
+			break;
+		case *Stuff:
+			var stuff = target.(*Stuff)
+			// skip the stuff already destroyed in this tick
+			if (stuff.Attr.HP <= 0) {
+				return
+			}
+			// separate the two circles and exchange the momentum by mass
+			g.resolveCollision(player_session_a.Player.GetGameObject(), stuff.GetGameObject())
+			
+			// give the collision damage
+			player_session_a.Player.Attr.HP -= g.Mode.OnDamage(g, player_session_a.Player, 0, float64(stuff.Attr.BodyDamage) * 5.0)
+			stuff.Attr.HP -= float64(player_session_a.Player.Status.BodyDamage) * 5.0
+			// deal with the dead
+			if (player_session_a.Player.Attr.HP <= 0) {
+				g.killPlayer(player_session_a, stuff.GameObject.Id, "stuff")
+			}
+			if (stuff.Attr.HP <= 0) {
+				// log the dead message
+				g.Logger.deadMessage(stuff.GameObject.Id, player_session_a.Player.GameObject.Id)
+				player_session_a.gainEXP(stuff.Attr.EXP)
+				// remove the stuff
+				g.removeStuff(stuff)
+			}
+			break;
+		case *Trap:
+			var trap = target.(*Trap)
+			// skip the trap already destroyed in this tick
+			if (trap.Attr.HP <= 0) {
+				return
+			}
+			// separate the two circles and exchange the momentum by mass
+			g.resolveCollision(player_session_a.Player.GetGameObject(), trap.GetGameObject())
+			
+			// give the collision damage
+			player_session_a.Player.Attr.HP -= g.Mode.OnDamage(g, player_session_a.Player, 0, float64(trap.Attr.BodyDamage) * 5.0)
+			trap.Attr.HP -= float64(player_session_a.Player.Status.BodyDamage) * 5.0
+			// deal with the dead
+			if (player_session_a.Player.Attr.HP <= 0) {
+				g.killPlayer(player_session_a, trap.GameObject.Id, "trap")
+			}
+			if (trap.Attr.HP <= 0) {
+				// log the dead message
+				g.Logger.deadMessage(trap.GameObject.Id, player_session_a.Player.GameObject.Id)
+				player_session_a.gainEXP(trap.Attr.EXP)
+				// remove the trap
+				g.removeTrap(trap)
+			}
+			break;
+		case *Bullet:
+			var bullet = target.(*Bullet)
+			// skip the bullet already destroyed in this tick
+			if (bullet.Existence <= 0) {
+				return
+			}
+			
+			// give the collision damage, the bullets pass through the owner and the players they can not hurt
+			var damage = g.Mode.OnDamage(g, player_session_a.Player, bullet.Team, float64(bullet.Damage) * 5.0)
+			if (bullet.Owner != player_session_a.Player.GameObject.Id) && (damage > 0) {
+				player_session_a.Player.Attr.HP -= damage
 				if (player_session_a.Player.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(player_session_a.Player.GameObject.Id, trap.GameObject.Id)
-					// send the dead message first
-					player_session_a.sendClientCommand(PlayerSessionCommand {
-						Method: "playerDead",
-						Params: CommandParams {},
-					})
-					player_session_a.ControlLock.Lock()
-					player_session_a.Alive = false
-					player_session_a.ControlLock.Unlock()
-				}
-				if (trap.Attr.HP <= 0) {
-					// log the dead message
-					g.Logger.deadMessage(trap.GameObject.Id, player_session_a.Player.GameObject.Id)
-					// remove the trap
-					g.MapInfo.Traps = append(g.MapInfo.Traps[:trap_index], g.MapInfo.Traps[trap_index+1:]...)
-				}
-				break;
-			case *Bullet:
-				target := collision.object_b.(*Bullet)
-				bullet_index := sort.Search(len(g.MapInfo.Bullets), func (i int) bool {
-					return g.MapInfo.Bullets[i].GameObject.Id == target.GameObject.Id
-				})
-				var bullet = g.MapInfo.Bullets[bullet_index]
-				
-				// give the collision damage
-				if (bullet.Owner != player_session_a.Player.Attr.Name) {
-					player_session_a.Player.Attr.HP -= float64(bullet.Damage) * 5.0
-					if (player_session_a.Player.Attr.HP <= 0) {
-						// log the dead message
-						g.Logger.deadMessage(player_session_a.Player.GameObject.Id, bullet.GameObject.Id)
-						// log the dead message
-						g.Logger.deadMessage(player_session_a.Player.GameObject.Id, bullet.GameObject.Id)
-						// send the dead message first
-						player_session_a.sendClientCommand(PlayerSessionCommand {
-							Method: "playerDead",
-							Params: CommandParams {},
-						})
-						player_session_a.ControlLock.Lock()
-						player_session_a.Alive = false
-						player_session_a.ControlLock.Unlock()
-					}
-					// remove the bullet
-					g.MapInfo.Bullets = append(g.MapInfo.Bullets[:bullet_index], g.MapInfo.Bullets[bullet_index+1:]...)
+					g.killPlayer(player_session_a, bullet.Owner, "bullet")
 				}
-				break;
-		}
+				// remove the bullet
+				bullet.Existence = 0
+				g.removeBullet(bullet)
+			}
+			break;
+	}
+}
+/**
+ * <*Game>.endRound:
+ * The function in Game to tell all players the round is over and let the game mode start the next round.
+ * The caller should hold the game lock.
+ *
+ * @return {nil}
+ */
+func (g *Game) endRound () {
+	log.Printf("Round of game room %s is over", g.Name)
+	var scores = g.Mode.TeamScores()
+	for _, ps := range g.Sessions {
+		ps.sendClientCommand(PlayerSessionCommand {
+			Method: "roundOver",
+			Params: CommandParams {
+				"teamScores": scores,
+			},
+		})
 	}
+	g.Mode.Reset(g)
 }
@@ -13,9 +13,11 @@ import (
  * The struct of game logger.
  *
  * @property {*logrus.Logger}					 													- the game logger instance
+ * @property {*os.File} file																		- the output file of the logger
  */
 type GameLogger struct {
 	instance *logrus.Logger
+	file *os.File
 }
 
 /**
@@ -38,6 +40,7 @@ func NewLogger (roomName string) *GameLogger {
 	baseLogger.SetOutput(file)
 	var gameLogger = &GameLogger {
 		instance: baseLogger,
+		file: file,
 	}
 	// write the first log
 	baseLogger.WithFields(logrus.Fields {
@@ -170,7 +173,7 @@ func (l *GameLogger) shootBullet (playerName string, number int, angle float64)
 
 /**
  * <*GameLogger>.playerEvaluation:
- * The function to record shoot bullet message.
+ * The function to record player evaluation message.
  *
  * @params {string} playerName																	- The player name
  * @params {string} attr																				- The target attribute to evaluate
@@ -185,7 +188,7 @@ func (l *GameLogger) shootBullet (playerName string, number int, angle float64)
 		"attribute": attr,
 		"origin": from,
 		"evaluated": to,
-	}).Info("Shoot bullet")
+	}).Info("Player evaluation")
 }
 
 /**
@@ -203,3 +206,101 @@ func (l *GameLogger) deadMessage (target, killedBy string) {
 		"killed-by": killedBy,
 	}).Info("Player dead")
 }
+
+
+/**
+ * <*GameLogger>.skipTicks:
+ * The function to record the ticks skipped by the overloaded game loop.
+ *
+ * @params {uint64} tick																				- The current tick number
+ * @params {int} skipped																				- The number of the skipped ticks
+ * 
+ * @return {nil}
+ */
+func (l *GameLogger) skipTicks (tick uint64, skipped int) {
+	l.instance.WithFields(logrus.Fields {
+		"tick": tick,
+		"skipped": skipped,
+	}).Warn("Skip overdue ticks")
+}
+
+/**
+ * <*GameLogger>.playerLevelUp:
+ * The function to record player level up message.
+ *
+ * @params {string} playerName																	- The player name
+ * @params {int} from																						- the origin level
+ * @params {int} to																							- the new level
+ * 
+ * @return {nil}
+ */
+func (l *GameLogger) playerLevelUp (playerName string, from, to int) {
+	l.instance.WithFields(logrus.Fields {
+		"player-name": playerName,
+		"origin": from,
+		"level": to,
+	}).Info("Player level up")
+}
+
+/**
+ * <*GameLogger>.playerUpgradeClass:
+ * The function to record player class upgrade message.
+ *
+ * @params {string} playerName																	- The player name
+ * @params {string} from																				- the origin class
+ * @params {string} to																					- the upgraded class
+ * 
+ * @return {nil}
+ */
+func (l *GameLogger) playerUpgradeClass (playerName, from, to string) {
+	l.instance.WithFields(logrus.Fields {
+		"player-name": playerName,
+		"origin": from,
+		"class": to,
+	}).Info("Player upgrade class")
+}
+
+/**
+ * <*GameLogger>.close:
+ * The function to record the closing of the room, flush and close the output file.
+ *
+ * @return {nil}
+ */
+func (l *GameLogger) close () {
+	if (l == nil) {
+		return
+	}
+	l.instance.Info("The room being closed")
+	l.file.Sync()
+	l.file.Close()
+}
+
+/**
+ * <*GameLogger>.kickPlayer:
+ * The function to record player kicked message.
+ *
+ * @params {string} playerName																	- The player name
+ * @params {string} reason																			- the reason to kick the player
+ * 
+ * @return {nil}
+ */
+func (l *GameLogger) kickPlayer (playerName, reason string) {
+	l.instance.WithFields(logrus.Fields {
+		"player-name": playerName,
+		"reason": reason,
+	}).Warn("Player kicked")
+}
+
+/**
+ * <*GameLogger>.broadcastMessage:
+ * The function to record server broadcast message.
+ *
+ * @params {string} message																			- The broadcast message
+ * 
+ * @return {nil}
+ */
+func (l *GameLogger) broadcastMessage (message string) {
+	l.instance.WithFields(logrus.Fields {
+		"message": message,
+	}).Info("Server broadcast")
+}
@@ -0,0 +1,543 @@
+package game
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the names of the game modes
+const FFAMode = "ffa"
+const Team2Mode = "team2"
+const Team4Mode = "team4"
+const RoyaleMode = "royale"
+
+/**
+ * GameMode:
+ * The interface of the rules which differ between the game modes, the game loop calls the hooks
+ * with the game lock held. OnDamage and SpawnPoint only decide, the game applies the result.
+ *
+ * @function {string} Name																		- the function to get the mode name
+ * @function {bool} CanJoin																		- the function to check if a new player can join now
+ * @function {nil} OnJoin																			- the function called before the diep of the joining player is put on the field
+ * @function {util.Vec2} SpawnPoint														- the function to get where the player enters the field
+ * @function {nil} OnTick																			- the function called after the collisions of every tick
+ * @function {float64} OnDamage																- the function to get the damage to apply, 0 if the attacker can not hurt the victim
+ * @function {bool} IsEnemy																		- the function to check if the other player is an opponent, whether or not it can be hurt now
+ * @function {nil} OnKill																			- the function called when a player dies
+ * @function {int, bool} RespawnLevel													- the function to get the level the dead player respawns at, false if not allowed
+ * @function {nil} OnLeave																		- the function called when the diep of the player is removed
+ * @function {bool} IsFinished																- the function to check if the round is over
+ * @function {nil} Reset																			- the function to start the next round after the round is over
+ * @function {[]int} TeamScores																- the function to get the team scores in the snapshots
+ * @function {ZoneSnapshot} Zone															- the function to get the safe zone in the snapshots
+ */
+type GameMode interface {
+	Name() string
+	CanJoin(g *Game) bool
+	OnJoin(g *Game, player *Player)
+	SpawnPoint(g *Game, player *Player) util.Vec2
+	OnTick(g *Game)
+	OnDamage(g *Game, victim *Player, attacker_team int, damage float64) float64
+	IsEnemy(g *Game, player, other *Player) bool
+	OnKill(g *Game, victim, killer *Player)
+	RespawnLevel(g *Game, player *Player) (int, bool)
+	OnLeave(g *Game, player *Player)
+	IsFinished(g *Game) bool
+	Reset(g *Game)
+	TeamScores() []int
+	Zone(g *Game) ZoneSnapshot
+}
+
+/**
+ * <game>.NewGameMode:
+ * The function to get the rules of the game mode in the room config.
+ *
+ * @param {RoomConfig} config																	- the room config
+ *
+ * @return {GameMode, error}
+ */
+func NewGameMode (config RoomConfig) (GameMode, error) {
+	switch (config.Mode) {
+		case FFAMode:
+			return &FFA { respawnKeep: config.RespawnKeep }, nil
+		case Team2Mode:
+			return NewTeamMode(config, 2), nil
+		case Team4Mode:
+			return NewTeamMode(config, 4), nil
+		case RoyaleMode:
+			return NewBattleRoyale(config), nil
+	}
+	return nil, errors.New("Unknown game mode " + config.Mode + "!")
+}
+
+/**
+ * FFA:
+ * The free-for-all mode, everyone fights everyone.
+ *
+ * @property {float64} respawnKeep														- the ratio of the level kept after respawn
+ */
+type FFA struct {
+	respawnKeep float64
+}
+
+/**
+ * <*FFA>.Name:
+ * The function in FFA to get the mode name.
+ *
+ * @return {string}
+ */
+func (m *FFA) Name () string {
+	return FFAMode
+}
+
+/**
+ * <*FFA>.CanJoin:
+ * The function in FFA to check if a new player can join now, always true.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *FFA) CanJoin (g *Game) bool {
+	return true
+}
+
+/**
+ * <*FFA>.OnJoin:
+ * The function in FFA to prepare the joining player, there is nothing to do.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the joining player
+ *
+ * @return {nil}
+ */
+func (m *FFA) OnJoin (g *Game, player *Player) {}
+
+/**
+ * <*FFA>.SpawnPoint:
+ * The function in FFA to get a random spawn point near the field origin.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the spawning player
+ *
+ * @return {util.Vec2}
+ */
+func (m *FFA) SpawnPoint (g *Game, player *Player) util.Vec2 {
+	return util.Vec2 {
+		X: rand.Float64() * 1023,
+		Y: rand.Float64() * 1023,
+	}
+}
+
+/**
+ * <*FFA>.OnTick:
+ * The function in FFA to apply the mode rules in every tick, there is none.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *FFA) OnTick (g *Game) {}
+
+/**
+ * <*FFA>.OnDamage:
+ * The function in FFA to get the damage to apply, everyone hurts everyone.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the player to be damaged
+ * @param {int} attacker_team																	- the team of the attacker, 0 for no team
+ * @param {float64} damage																		- the damage by the collision
+ *
+ * @return {float64}
+ */
+func (m *FFA) OnDamage (g *Game, victim *Player, attacker_team int, damage float64) float64 {
+	return damage
+}
+
+/**
+ * <*FFA>.IsEnemy:
+ * The function in FFA to check if the other player is an opponent, everyone is.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the player
+ * @param {*Player} other																			- the other player
+ *
+ * @return {bool}
+ */
+func (m *FFA) IsEnemy (g *Game, player, other *Player) bool {
+	return true
+}
+
+/**
+ * <*FFA>.OnKill:
+ * The function in FFA to count the kill, there is no team score.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the dead player
+ * @param {*Player} killer																		- the killer, nil if killed by the others
+ *
+ * @return {nil}
+ */
+func (m *FFA) OnKill (g *Game, victim, killer *Player) {}
+
+/**
+ * <*FFA>.RespawnLevel:
+ * The function in FFA to get the level the dead player respawns at.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the dead player
+ *
+ * @return {int, bool}
+ */
+func (m *FFA) RespawnLevel (g *Game, player *Player) (int, bool) {
+	return keptLevel(player.Attr.Level, m.respawnKeep), true
+}
+
+/**
+ * <*FFA>.OnLeave:
+ * The function in FFA to forget the leaving player, there is nothing to do.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the leaving player
+ *
+ * @return {nil}
+ */
+func (m *FFA) OnLeave (g *Game, player *Player) {}
+
+/**
+ * <*FFA>.IsFinished:
+ * The function in FFA to check if the round is over, the game never ends.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *FFA) IsFinished (g *Game) bool {
+	return false
+}
+
+/**
+ * <*FFA>.Reset:
+ * The function in FFA to start the next round, there is nothing to do.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *FFA) Reset (g *Game) {}
+
+/**
+ * <*FFA>.TeamScores:
+ * The function in FFA to get the team scores, always empty.
+ *
+ * @return {[]int}
+ */
+func (m *FFA) TeamScores () []int {
+	return []int {}
+}
+
+/**
+ * <*FFA>.Zone:
+ * The function in FFA to get the safe zone, there is none.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {ZoneSnapshot}
+ */
+func (m *FFA) Zone (g *Game) ZoneSnapshot {
+	return ZoneSnapshot {}
+}
+
+/**
+ * TeamMode:
+ * The team deathmatch mode, the teams own the bases at the field corners and score by killing the enemies.
+ *
+ * @property {int} teams																			- the number of the teams
+ * @property {[]int} scores																		- the kills of every team
+ * @property {bool} friendlyFire															- whether the teammates can damage each other
+ * @property {float64} baseRadius															- the radius of the bases
+ * @property {float64} baseDamage															- the HP per second the bases take from the enemies inside
+ * @property {float64} respawnKeep														- the ratio of the level kept after respawn
+ */
+type TeamMode struct {
+	teams int
+	scores []int
+	friendlyFire bool
+	baseRadius float64
+	baseDamage float64
+	respawnKeep float64
+}
+
+/**
+ * <game>.NewTeamMode:
+ * The function to new the team deathmatch mode.
+ *
+ * @param {RoomConfig} config																	- the room config
+ * @param {int} teams																					- the number of the teams, 2 or 4
+ *
+ * @return {*TeamMode}
+ */
+func NewTeamMode (config RoomConfig, teams int) *TeamMode {
+	return &TeamMode {
+		teams: teams,
+		scores: make([]int, teams),
+		friendlyFire: config.FriendlyFire,
+		baseRadius: config.BaseRadius,
+		baseDamage: config.BaseDamage,
+		respawnKeep: config.RespawnKeep,
+	}
+}
+
+/**
+ * <*TeamMode>.Name:
+ * The function in TeamMode to get the mode name.
+ *
+ * @return {string}
+ */
+func (m *TeamMode) Name () string {
+	if (m.teams == 4) {
+		return Team4Mode
+	}
+	return Team2Mode
+}
+
+/**
+ * <*TeamMode>.CanJoin:
+ * The function in TeamMode to check if a new player can join now, always true.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *TeamMode) CanJoin (g *Game) bool {
+	return true
+}
+
+/**
+ * <*TeamMode>.baseOf:
+ * The function in TeamMode to get the corner of the team base, the 2 teams take the opposite corners.
+ *
+ * @param {*Game} g																						- the game
+ * @param {int} team																					- the team from 1
+ *
+ * @return {util.Vec2}
+ */
+func (m *TeamMode) baseOf (g *Game, team int) util.Vec2 {
+	var corners = []util.Vec2 {
+		{ X: 0, Y: 0 },
+		{ X: g.Field.W, Y: g.Field.H },
+		{ X: g.Field.W, Y: 0 },
+		{ X: 0, Y: g.Field.H },
+	}
+	return corners[(team - 1) % len(corners)]
+}
+
+/**
+ * <*TeamMode>.OnJoin:
+ * The function in TeamMode to put the joining player in the smallest team.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the joining player
+ *
+ * @return {nil}
+ */
+func (m *TeamMode) OnJoin (g *Game, player *Player) {
+	var members = make([]int, m.teams + 1)
+	for _, ps := range g.Sessions {
+		if (ps.Player != player) {
+			members[ps.Player.Team]++
+		}
+	}
+	for _, suspended := range g.suspended {
+		members[suspended.session.Player.Team]++
+	}
+	player.Team = 1
+	for team := 2; team <= m.teams; team++ {
+		if (members[team] < members[player.Team]) {
+			player.Team = team
+		}
+	}
+}
+
+/**
+ * <*TeamMode>.SpawnPoint:
+ * The function in TeamMode to get a random point inside the base of the player team.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the spawning player
+ *
+ * @return {util.Vec2}
+ */
+func (m *TeamMode) SpawnPoint (g *Game, player *Player) util.Vec2 {
+	var corner = m.baseOf(g, player.Team)
+	var angle = rand.Float64() * math.Pi / 2
+	var offset = util.Vec2 { X: math.Cos(angle), Y: math.Sin(angle) }.Scale(rand.Float64() * m.baseRadius * 0.8)
+	if (corner.X > 0) {
+		offset.X = -offset.X
+	}
+	if (corner.Y > 0) {
+		offset.Y = -offset.Y
+	}
+	return corner.Add(offset)
+}
+
+/**
+ * <*TeamMode>.OnTick:
+ * The function in TeamMode to damage the enemies inside the bases.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *TeamMode) OnTick (g *Game) {
+	for _, ps := range g.Sessions {
+		if (!ps.Playing()) {
+			continue
+		}
+		for team := 1; team <= m.teams; team++ {
+			if (team == ps.Player.Team) || (m.baseOf(g, team).Sub(ps.Player.Position).Length() > m.baseRadius) {
+				continue
+			}
+			ps.Player.Attr.HP -= m.baseDamage / g.Framerate
+			if (ps.Player.Attr.HP <= 0) {
+				g.killPlayer(ps, "", "base")
+				break
+			}
+		}
+	}
+}
+
+/**
+ * <*TeamMode>.OnKill:
+ * The function in TeamMode to give the team of the killer one point for killing an enemy.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the dead player
+ * @param {*Player} killer																		- the killer, nil if killed by the others
+ *
+ * @return {nil}
+ */
+func (m *TeamMode) OnKill (g *Game, victim, killer *Player) {
+	if (killer == nil) || (killer.Team < 1) || (killer.Team > m.teams) || (killer.Team == victim.Team) {
+		return
+	}
+	m.scores[killer.Team - 1]++
+}
+
+/**
+ * <*TeamMode>.RespawnLevel:
+ * The function in TeamMode to get the level the dead player respawns at in its base.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the dead player
+ *
+ * @return {int, bool}
+ */
+func (m *TeamMode) RespawnLevel (g *Game, player *Player) (int, bool) {
+	return keptLevel(player.Attr.Level, m.respawnKeep), true
+}
+
+/**
+ * <*TeamMode>.OnDamage:
+ * The function in TeamMode to get the damage to apply, the teammates are protected unless the friendly fire is on.
+ * The team 0 is neutral and hurts everyone.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} victim																		- the player to be damaged
+ * @param {int} attacker_team																	- the team of the attacker, 0 for no team
+ * @param {float64} damage																		- the damage by the collision
+ *
+ * @return {float64}
+ */
+func (m *TeamMode) OnDamage (g *Game, victim *Player, attacker_team int, damage float64) float64 {
+	if (!m.friendlyFire) && (attacker_team != 0) && (attacker_team == victim.Team) {
+		return 0
+	}
+	return damage
+}
+
+/**
+ * <*TeamMode>.IsEnemy:
+ * The function in TeamMode to check if the other player is an opponent, the teammates are not even with the friendly fire.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the player
+ * @param {*Player} other																			- the other player
+ *
+ * @return {bool}
+ */
+func (m *TeamMode) IsEnemy (g *Game, player, other *Player) bool {
+	return player.Team != other.Team
+}
+
+/**
+ * <*TeamMode>.OnLeave:
+ * The function in TeamMode to forget the leaving player, the team sizes are counted on join.
+ *
+ * @param {*Game} g																						- the game
+ * @param {*Player} player																		- the leaving player
+ *
+ * @return {nil}
+ */
+func (m *TeamMode) OnLeave (g *Game, player *Player) {}
+
+/**
+ * <*TeamMode>.IsFinished:
+ * The function in TeamMode to check if the round is over, the deathmatch never ends.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {bool}
+ */
+func (m *TeamMode) IsFinished (g *Game) bool {
+	return false
+}
+
+/**
+ * <*TeamMode>.Reset:
+ * The function in TeamMode to start the next round with the scores cleared.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {nil}
+ */
+func (m *TeamMode) Reset (g *Game) {
+	m.scores = make([]int, m.teams)
+}
+
+/**
+ * <*TeamMode>.TeamScores:
+ * The function in TeamMode to get the copy of the team scores.
+ *
+ * @return {[]int}
+ */
+func (m *TeamMode) TeamScores () []int {
+	return append([]int {}, m.scores...)
+}
+
+/**
+ * <*TeamMode>.Zone:
+ * The function in TeamMode to get the safe zone, there is none.
+ *
+ * @param {*Game} g																						- the game
+ *
+ * @return {ZoneSnapshot}
+ */
+func (m *TeamMode) Zone (g *Game) ZoneSnapshot {
+	return ZoneSnapshot {}
+}
+
+/**
+ * <game>.keptLevel:
+ * The function to get the level kept after respawn, at least 1.
+ *
+ * @param {int} level																					- the level at death
+ * @param {float64} keep																			- the ratio of the level kept
+ *
+ * @return {int}
+ */
+func keptLevel (level int, keep float64) int {
+	return int(math.Max(math.Floor(float64(level) * keep), 1))
+}
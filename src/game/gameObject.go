@@ -9,19 +9,21 @@ import (
  * The struct of game object.
  *
  * @property {string} Id					 														- the unique identity between game objects
- * @property {util.Point} Position														- the position on screen
+ * @property {uint32} NetId																		- the compact identity on the wire, assigned when the object is indexed
+ * @property {util.Vec2} Position														- the position on screen
  * @property {float64} Mass																		- the mass of the game object
  * @property {float64} Radius																	- the collision circle area radius
- * @property {util.VelocityFormat} Velocity										- the velocity of the game object
- * @property {util.AccelerationFormat} Acceleration						- the velocity of the game object
+ * @property {util.Vec2} Velocity															- the velocity of the game object
+ * @property {util.Vec2} Acceleration													- the acceleration of the game object
  */
  type GameObject struct {
 	Id string
-	Position util.Point
+	NetId uint32
+	Position util.Vec2
 	Mass float64
 	Radius float64
-	Velocity util.VelocityFormat
-	Acceleration util.AccelerationFormat
+	Velocity util.Vec2
+	Acceleration util.Vec2
 	Rotation float64
 }
 
@@ -30,9 +32,11 @@ import (
  * The interface of game object.
  *
  * @function {string} GetId					 													- the function to get the game object id
+ * @function {*GameObject} GetGameObject			 								- the function to get the game object struct
  */
 type GameObjectInterface interface {
 	GetId() string
+	GetGameObject() *GameObject
 }
 
 /**
@@ -43,4 +47,26 @@ type GameObjectInterface interface {
  */
 func (g *GameObject) GetId() string {
 	return g.Id
+}
+
+/**
+ * <*GameObject>.GetGameObject:
+ * The function to get the game object struct itself
+ * 
+ * @return {*GameObject}
+ */
+func (g *GameObject) GetGameObject() *GameObject {
+	return g
+}
+
+/**
+ * <*GameObject>.Overlap:
+ * The function to check if the collision circle overlaps the target one
+ *
+ * @param {*GameObject} target					 											- the target game object
+ * 
+ * @return {bool}
+ */
+func (g *GameObject) Overlap(target *GameObject) bool {
+	return g.Position.Sub(target.Position).Length() <= g.Radius + target.Radius
 }
\ No newline at end of file
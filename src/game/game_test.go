@@ -0,0 +1,203 @@
+package game
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+/**
+ * <game>.TestMain:
+ * The function to run the tests in a temporary directory with the config files and the log directory,
+ * since the game reads both relative to the working directory.
+ *
+ * @param {*testing.M} m																			- the test runner
+ *
+ * @return {nil}
+ */
+func TestMain (m *testing.M) {
+	config, err := filepath.Abs("../config")
+	if (err != nil) {
+		panic(err)
+	}
+	dir, err := ioutil.TempDir("", "game-test")
+	if (err != nil) {
+		panic(err)
+	}
+	os.Mkdir(filepath.Join(dir, "src"), 0755)
+	os.Mkdir(filepath.Join(dir, "logs"), 0755)
+	if err := os.Symlink(config, filepath.Join(dir, "src", "config")); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	var code = m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+/**
+ * <game>.TestCloseStopsRoutines:
+ * The test to check that closing the game stops runListen, loop, generateStuff, generateTrap and runBots.
+ */
+func TestCloseStopsRoutines (t *testing.T) {
+	var baseline = runtime.NumGoroutine()
+	var config = DefaultRoomConfig()
+	config.BotCount = 2
+	var g = NewGame("routine-test", config)
+	// let the bots join in the first balance
+	time.Sleep(1200 * time.Millisecond)
+	if running := runtime.NumGoroutine(); running < baseline + 5 {
+		t.Fatalf("%d routines running after NewGame, want at least %d", running, baseline + 5)
+	}
+	g.Close()
+	// give the stopped routines a moment to return
+	var deadline = time.Now().Add(time.Second)
+	for (runtime.NumGoroutine() > baseline) && (time.Now().Before(deadline)) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if left := runtime.NumGoroutine(); left > baseline {
+		var stacks = make([]byte, 1 << 16)
+		stacks = stacks[:runtime.Stack(stacks, true)]
+		t.Fatalf("%d routines left after Close, %d before NewGame:\n%s", left, baseline, stacks)
+	}
+}
+
+/**
+ * <game>.newCollisionGame:
+ * The function to get the game with the dieps and stuffs placed randomly for the collision tests,
+ * no routine is started.
+ *
+ * @param {int} dieps																					- the number of the dieps
+ * @param {int} stuffs																				- the number of the stuffs
+ *
+ * @return {*Game}
+ */
+func newCollisionGame (dieps, stuffs int) *Game {
+	var random = rand.New(rand.NewSource(1))
+	var field = &util.Size { W: 8192, H: 8192 }
+	var g = &Game {
+		Field: field,
+		MapInfo: Map {
+			Dieps: []*Diep {},
+			Stuffs: []*Stuff {},
+			Traps: []*Trap {},
+			Index: NewMapIndex(field),
+		},
+	}
+	for i := 0; i < dieps; i++ {
+		g.MapInfo.Dieps = append(g.MapInfo.Dieps, &Diep {
+			GameObject: &GameObject {
+				Id: "diep-" + strconv.Itoa(i),
+				Position: util.Vec2 { X: random.Float64() * field.W, Y: random.Float64() * field.H },
+				Radius: 50,
+			},
+		})
+	}
+	for i := 0; i < stuffs; i++ {
+		g.MapInfo.Stuffs = append(g.MapInfo.Stuffs, &Stuff {
+			GameObject: GameObject {
+				Id: "stuff-" + strconv.Itoa(i),
+				Position: util.Vec2 { X: random.Float64() * field.W, Y: random.Float64() * field.H },
+				Radius: 20,
+			},
+		})
+	}
+	return g
+}
+
+/**
+ * <*Game>.detectPairwise:
+ * The function in Game to detect the diep and stuff collisions by testing every pair, the way before the spatial index.
+ *
+ * @return {nil}
+ */
+func (g *Game) detectPairwise () {
+	for _, diep_a := range g.MapInfo.Dieps {
+		for _, diep_b := range g.MapInfo.Dieps {
+			if (diep_a.GameObject.Id < diep_b.GameObject.Id) && (diep_a.GameObject.Overlap(diep_b.GameObject)) {
+				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
+					object_a: diep_a,
+					object_b: diep_b,
+				})
+			}
+		}
+		for _, stuff := range g.MapInfo.Stuffs {
+			if (diep_a.GameObject.Overlap(&stuff.GameObject)) {
+				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
+					object_a: diep_a,
+					object_b: stuff,
+				})
+			}
+		}
+	}
+}
+
+/**
+ * <*Game>.detectIndexed:
+ * The function in Game to detect the diep and stuff collisions through the spatial index, as the game loop does.
+ *
+ * @return {nil}
+ */
+func (g *Game) detectIndexed () {
+	g.rebuildIndex()
+	g.detectDeipCollision()
+	g.detectStuffCollision()
+}
+
+/**
+ * <game>.TestDetectCollisionsMatchesPairwise:
+ * The test to check that the spatial index finds the same collisions as testing every pair.
+ */
+func TestDetectCollisionsMatchesPairwise (t *testing.T) {
+	var g = newCollisionGame(100, 1000)
+	g.detectPairwise()
+	var pairwise = map[CollisionDetection]bool {}
+	for _, collision := range g.MapInfo.Collisions {
+		pairwise[collision] = true
+	}
+	if (len(pairwise) == 0) {
+		t.Fatal("the random field has no collision to compare")
+	}
+	g.MapInfo.Collisions = []CollisionDetection {}
+	g.detectIndexed()
+	if (len(g.MapInfo.Collisions) != len(pairwise)) {
+		t.Fatalf("index found %d collisions, pairwise found %d", len(g.MapInfo.Collisions), len(pairwise))
+	}
+	for _, collision := range g.MapInfo.Collisions {
+		if (!pairwise[collision]) {
+			t.Fatalf("index found the collision %v not found pairwise", collision)
+		}
+	}
+}
+
+/**
+ * <game>.BenchmarkDetectCollisions:
+ * The benchmark to compare testing every pair with querying the spatial index for 100 dieps and 1000 stuffs.
+ */
+func BenchmarkDetectCollisions (b *testing.B) {
+	var detects = []struct {
+		name string
+		detect func (g *Game)
+	} {
+		{ "pairwise", (*Game).detectPairwise },
+		{ "index", (*Game).detectIndexed },
+	}
+	for _, detect := range detects {
+		b.Run(detect.name, func (b *testing.B) {
+			var g = newCollisionGame(100, 1000)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.MapInfo.Collisions = g.MapInfo.Collisions[:0]
+				detect.detect(g)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"log"
+)
+
+// define the max number of the inputs waiting for the next tick, the newer ones are dropped over it
+const maxPendingInputs = 64
+
+/**
+ * playerInput:
+ * The struct of one client input waiting to be applied in the next tick.
+ *
+ * @property {uint32} Seq																			- the input sequence number from client, 0 if not given
+ * @property {float64} Time																		- the client timestamp of the input
+ * @property {string} Method																	- the action type of the input
+ * @property {CommandParams} Params														- the option of the input
+ */
+type playerInput struct {
+	Seq uint32
+	Time float64
+	Method string
+	Params CommandParams
+}
+
+/**
+ * <game>.isInputMethod:
+ * The function to check if the command is an input applied in the game tick.
+ *
+ * @param {string} method																			- the method of the command
+ *
+ * @return {bool}
+ */
+func isInputMethod (method string) bool {
+	switch (method) {
+		case "moveUp", "moveDown", "moveLeft", "moveRight", "shoot":
+			return true
+	}
+	return false
+}
+
+/**
+ * <*PlayerSession>.acceptSeq:
+ * The function in PlayerSession to check the sequence number of the command from client.
+ * The commands without the sequence number are always accepted.
+ *
+ * @param {PlayerSessionCommand} command											- the command from client
+ *
+ * @return {bool}																							- false if the command is out of order or duplicated
+ */
+func (ps *PlayerSession) acceptSeq (command PlayerSessionCommand) bool {
+	seq, _ := command.Params["seq"].(float64)
+	if (seq <= 0) {
+		return true
+	}
+	ps.ControlLock.Lock()
+	defer ps.ControlLock.Unlock()
+	if (uint32(seq) <= ps.inputSeq) {
+		return false
+	}
+	ps.inputSeq = uint32(seq)
+	return true
+}
+
+/**
+ * <*PlayerSession>.queueInput:
+ * The function in PlayerSession to keep the input until the next game tick.
+ * The out-of-order and duplicate inputs are dropped, and the sequence number only advances when the input is kept,
+ * so the input dropped over the pending limit is never acknowledged.
+ *
+ * @param {PlayerSessionCommand} command											- the input command from client
+ *
+ * @return {bool}																							- false if the input is dropped
+ */
+func (ps *PlayerSession) queueInput (command PlayerSessionCommand) bool {
+	seq, _ := command.Params["seq"].(float64)
+	time, _ := command.Params["time"].(float64)
+	ps.ControlLock.Lock()
+	defer ps.ControlLock.Unlock()
+	if (seq > 0) && (uint32(seq) <= ps.inputSeq) {
+		return false
+	}
+	if (len(ps.inputs) >= maxPendingInputs) {
+		log.Printf("Player %s sent too many inputs in one tick", ps.Player.Attr.Name)
+		return false
+	}
+	if (seq > 0) {
+		ps.inputSeq = uint32(seq)
+	}
+	ps.inputs = append(ps.inputs, playerInput {
+		Seq: uint32(seq),
+		Time: time,
+		Method: command.Method,
+		Params: command.Params,
+	})
+	return true
+}
+
+/**
+ * <*Game>.applyInputs:
+ * The function in Game to apply the inputs of all sessions in the order of arrival.
+ * The inputs are already in sequence order since the late ones are dropped on arrival.
+ * The caller should hold the game lock.
+ *
+ * @return {nil}
+ */
+func (g *Game) applyInputs () {
+	for _, ps := range g.Sessions {
+		ps.ControlLock.Lock()
+		for _, input := range ps.inputs {
+			ps.applyInput(input)
+			if (input.Seq > 0) {
+				ps.processedSeq = input.Seq
+				ps.processedTime = input.Time
+			}
+		}
+		ps.inputs = ps.inputs[:0]
+		ps.ControlLock.Unlock()
+	}
+}
+
+/**
+ * <*PlayerSession>.applyInput:
+ * The function in PlayerSession to apply one input to the player.
+ * The caller should hold the game lock and the session lock.
+ *
+ * @param {playerInput} input																	- the input to apply
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) applyInput (input playerInput) {
+	// the dead player can not move or shoot until respawn
+	if (ps.Dead) {
+		return
+	}
+	switch (input.Method) {
+		case "moveUp":
+			ps.Moving.Up, _ = input.Params["value"].(bool)
+		case "moveDown":
+			ps.Moving.Down, _ = input.Params["value"].(bool)
+		case "moveLeft":
+			ps.Moving.Left, _ = input.Params["value"].(bool)
+		case "moveRight":
+			ps.Moving.Right, _ = input.Params["value"].(bool)
+		case "shoot":
+			angle, _ := input.Params["angle"].(float64)
+			ps.Shoot(angle)
+	}
+}
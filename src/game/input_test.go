@@ -0,0 +1,46 @@
+package game
+
+import (
+	"testing"
+)
+
+/**
+ * <game>.TestQueueInputSeq:
+ * The test to check that the input dropped over the pending limit does not advance the sequence number,
+ * and the out-of-order inputs are dropped.
+ */
+func TestQueueInputSeq (t *testing.T) {
+	var ps = &PlayerSession {
+		Player: &Player {
+			Attr: PlayerAttribute { Name: "fast" },
+		},
+	}
+	var input = func (seq int) PlayerSessionCommand {
+		return PlayerSessionCommand {
+			Method: "moveUp",
+			Params: CommandParams { "seq": float64(seq), "value": true },
+		}
+	}
+	for seq := 1; seq <= maxPendingInputs; seq++ {
+		if (!ps.queueInput(input(seq))) {
+			t.Fatalf("input %d dropped", seq)
+		}
+	}
+	if (ps.queueInput(input(maxPendingInputs + 1))) {
+		t.Error("input over the pending limit queued")
+	}
+	if (ps.inputSeq != maxPendingInputs) {
+		t.Errorf("sequence advanced to %d by the dropped input, want %d", ps.inputSeq, maxPendingInputs)
+	}
+	// the tick applies the pending inputs, then the dropped input can be sent again
+	ps.inputs = ps.inputs[:0]
+	if (!ps.queueInput(input(maxPendingInputs + 1))) {
+		t.Error("input sent again after the tick dropped")
+	}
+	if (ps.queueInput(input(10))) || (ps.queueInput(input(maxPendingInputs + 1))) {
+		t.Error("out-of-order or duplicate input queued")
+	}
+	if (!ps.queueInput(PlayerSessionCommand { Method: "shoot", Params: CommandParams {} })) {
+		t.Error("input without the sequence number dropped")
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"math"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the weight of the new sample in the smoothed round trip time
+const rttSmoothing = 0.2
+
+/**
+ * positionFrame:
+ * The struct of the diep positions at the end of one tick, kept for rewinding the bullet hit test.
+ *
+ * @property {uint64} Tick																		- the tick of the frame
+ * @property {map[uint32]util.Vec2} Positions									- the diep positions by network id
+ */
+type positionFrame struct {
+	Tick uint64
+	Positions map[uint32]util.Vec2
+}
+
+/**
+ * <game>.newPositionHistory:
+ * The function to get the ring buffer long enough for the max rewind of the room.
+ *
+ * @param {RoomConfig} config																	- the room config
+ *
+ * @return {[]positionFrame}																	- empty if the lag compensation is disabled
+ */
+func newPositionHistory (config RoomConfig) []positionFrame {
+	if (config.MaxRewind <= 0) {
+		return []positionFrame {}
+	}
+	return make([]positionFrame, int(math.Ceil(config.MaxRewind * config.TickRate)) + 1)
+}
+
+/**
+ * <*Game>.recordHistory:
+ * The function in Game to keep the diep positions at the end of the tick.
+ * The caller should hold the game lock.
+ *
+ * @return {nil}
+ */
+func (g *Game) recordHistory () {
+	if (len(g.history) == 0) {
+		return
+	}
+	var frame = &g.history[g.Tick % uint64(len(g.history))]
+	frame.Tick = g.Tick
+	if (frame.Positions == nil) {
+		frame.Positions = map[uint32]util.Vec2 {}
+	}
+	for id := range frame.Positions {
+		delete(frame.Positions, id)
+	}
+	for _, diep := range g.MapInfo.Dieps {
+		frame.Positions[diep.NetId] = diep.Position
+	}
+}
+
+/**
+ * <*Game>.rewoundPosition:
+ * The function in Game to get the diep position the ticks before.
+ *
+ * @param {*Diep} diep																				- the target diep
+ * @param {int} rewind																				- the number of ticks to rewind
+ *
+ * @return {util.Vec2}																				- the current position if the tick is not kept
+ */
+func (g *Game) rewoundPosition (diep *Diep, rewind int) util.Vec2 {
+	if (rewind <= 0) || (len(g.history) == 0) || (uint64(rewind) > g.Tick) {
+		return diep.Position
+	}
+	var tick = g.Tick - uint64(rewind)
+	var frame = &g.history[tick % uint64(len(g.history))]
+	if (frame.Tick != tick) {
+		return diep.Position
+	}
+	if position, ok := frame.Positions[diep.NetId]; ok {
+		return position
+	}
+	return diep.Position
+}
+
+/**
+ * <*Game>.rewindReach:
+ * The function in Game to get the farthest distance between the diep and its kept positions.
+ *
+ * @param {*Diep} diep																				- the target diep
+ *
+ * @return {float64}
+ */
+func (g *Game) rewindReach (diep *Diep) float64 {
+	var reach = 0.0
+	for i := range g.history {
+		if position, ok := g.history[i].Positions[diep.NetId]; ok {
+			reach = math.Max(reach, position.Sub(diep.Position).Length())
+		}
+	}
+	return reach
+}
+
+/**
+ * <*PlayerSession>.updateRTT:
+ * The function in PlayerSession to smooth the round trip time with the pong of the ping sent at the time.
+ * The caller should hold the session lock.
+ *
+ * @param {float64} sent																			- the server timestamp in millisecond echoed by the pong
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) updateRTT (sent float64) {
+	var sample = time.Duration((float64(time.Now().UnixNano()) / 1e6 - sent) * float64(time.Millisecond))
+	if (sample < 0) {
+		return
+	}
+	if (ps.rtt == 0) {
+		ps.rtt = sample
+		return
+	}
+	ps.rtt = time.Duration(float64(ps.rtt) * (1 - rttSmoothing) + float64(sample) * rttSmoothing)
+}
+
+/**
+ * <*PlayerSession>.rewindTicks:
+ * The function in PlayerSession to get the number of ticks to rewind the hit test of the player bullets.
+ * The caller should hold the session lock.
+ *
+ * @return {int}
+ */
+func (ps *PlayerSession) rewindTicks () int {
+	var max_ticks = int(ps.Game.Config.MaxRewind * ps.Game.Framerate)
+	var ticks = int(math.Round(ps.rtt.Seconds() * ps.Game.Framerate))
+	if (ticks > max_ticks) {
+		return max_ticks
+	}
+	return ticks
+}
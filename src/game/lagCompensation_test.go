@@ -0,0 +1,130 @@
+package game
+
+import (
+	"testing"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+/**
+ * <game>.newLagGame:
+ * The function to get the game with the one target diep and the position history for the lag compensation tests,
+ * no routine is started.
+ *
+ * @param {util.Vec2} position																- the position of the target diep
+ *
+ * @return {*Game, *Diep}
+ */
+func newLagGame (position util.Vec2) (*Game, *Diep) {
+	var config = DefaultRoomConfig()
+	var field = &config.Field
+	var g = &Game {
+		Field: field,
+		Framerate: config.TickRate,
+		Config: config,
+		MapInfo: Map {
+			Dieps: []*Diep {},
+			Bullets: []*Bullet {},
+			Stuffs: []*Stuff {},
+			Traps: []*Trap {},
+			Index: NewMapIndex(field),
+		},
+		history: newPositionHistory(config),
+	}
+	var target = &Diep {
+		GameObject: &GameObject {
+			Id: "target",
+			Position: position,
+			Radius: 50,
+		},
+	}
+	g.MapInfo.Dieps = append(g.MapInfo.Dieps, target)
+	return g, target
+}
+
+/**
+ * <game>.TestRewoundBulletHitsRecordedPosition:
+ * The test to check that the bullet of the lagged shooter hits the target where the shooter saw it, not where it is now,
+ * and keeps doing so after the tick it is shot.
+ */
+func TestRewoundBulletHitsRecordedPosition (t *testing.T) {
+	var recorded = util.Vec2 { X: 1000, Y: 1000 }
+	var current = util.Vec2 { X: 1300, Y: 1000 }
+	var g, target = newLagGame(recorded)
+	for tick := uint64(1); tick <= 5; tick++ {
+		g.Tick = tick
+		g.rebuildIndex()
+		g.recordHistory()
+	}
+	// the target moves away in the tick the bullets are tested
+	target.Position = current
+	var lagged_at_recorded = &Bullet { GameObject: GameObject { Id: "lagged-recorded", Position: recorded, Radius: 5 }, Rewind: 3 }
+	var lagged_at_current = &Bullet { GameObject: GameObject { Id: "lagged-current", Position: current, Radius: 5 }, Rewind: 3 }
+	var unlagged_at_recorded = &Bullet { GameObject: GameObject { Id: "unlagged-recorded", Position: recorded, Radius: 5 } }
+	var unlagged_at_current = &Bullet { GameObject: GameObject { Id: "unlagged-current", Position: current, Radius: 5 } }
+	g.MapInfo.Bullets = []*Bullet { lagged_at_recorded, lagged_at_current, unlagged_at_recorded, unlagged_at_current }
+	var want = map[*Bullet]bool {
+		lagged_at_recorded: true,
+		lagged_at_current: false,
+		unlagged_at_recorded: false,
+		unlagged_at_current: true,
+	}
+	// the bullet keeps the rewind in the later ticks of its life
+	for tick := uint64(6); tick <= 8; tick++ {
+		g.Tick = tick
+		g.rebuildIndex()
+		g.MapInfo.Collisions = []CollisionDetection {}
+		g.detectBulletCollision()
+		var hit = map[*Bullet]bool {}
+		for _, collision := range g.MapInfo.Collisions {
+			hit[collision.object_b.(*Bullet)] = true
+		}
+		for bullet, want_hit := range want {
+			if (hit[bullet] != want_hit) {
+				t.Errorf("tick %d: bullet %s hit %v, want %v", tick, bullet.Id, hit[bullet], want_hit)
+			}
+		}
+		// the frames rewound to in these ticks still keep the recorded position
+		g.recordHistory()
+	}
+}
+
+/**
+ * <game>.TestRewoundPositionOutOfHistory:
+ * The test to check that the ticks not kept in the history fall back to the current position.
+ */
+func TestRewoundPositionOutOfHistory (t *testing.T) {
+	var recorded = util.Vec2 { X: 1000, Y: 1000 }
+	var g, target = newLagGame(recorded)
+	g.Tick = 1
+	g.rebuildIndex()
+	g.recordHistory()
+	g.Tick = 2
+	target.Position = util.Vec2 { X: 2000, Y: 2000 }
+	if got := g.rewoundPosition(target, 1); got != recorded {
+		t.Errorf("rewound by 1 tick to %v, want %v", got, recorded)
+	}
+	if got := g.rewoundPosition(target, 0); got != target.Position {
+		t.Errorf("rewound by 0 tick to %v, want the current %v", got, target.Position)
+	}
+	if got := g.rewoundPosition(target, len(g.history) + 5); got != target.Position {
+		t.Errorf("rewound beyond the history to %v, want the current %v", got, target.Position)
+	}
+}
+
+/**
+ * <game>.TestRewindTicksCapped:
+ * The test to check that the rewind follows the round trip time and stops at the max rewind of the room.
+ */
+func TestRewindTicksCapped (t *testing.T) {
+	var g, _ = newLagGame(util.Vec2 {})
+	var ps = &PlayerSession { Game: g }
+	ps.rtt = 100 * time.Millisecond
+	if got := ps.rewindTicks(); got != 5 {
+		t.Errorf("rewind for 100ms at 50 ticks is %d, want 5", got)
+	}
+	ps.rtt = time.Second
+	if got, max := ps.rewindTicks(), int(g.Config.MaxRewind * g.Framerate); got != max {
+		t.Errorf("rewind for 1s is %d, want the max %d", got, max)
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sync"
+)
+
+/**
+ * LevelCurve:
+ * The struct of the level curve loaded from the config file.
+ *
+ * @property {int} MaxLevel																		- the max level of the player
+ * @property {float64} BaseEXP																- the EXP needed from level 1 to level 2
+ * @property {float64} GrowthRate															- the ratio of the EXP needed between two adjacent levels
+ * @property {int} SkillPointsPerLevel												- the skill points granted in every level up
+ * @property {int} ScorePerEXP																- the score granted by every EXP
+ */
+type LevelCurve struct {
+	MaxLevel int
+	BaseEXP float64
+	GrowthRate float64
+	SkillPointsPerLevel int
+	ScorePerEXP int
+}
+
+// keep the level curve loaded once for all games
+var levelCurve LevelCurve
+var levelCurveOnce sync.Once
+
+/**
+ * <game>.GetLevelCurve:
+ * The function to get the level curve, it reads the config file at the first call.
+ *
+ * @return {LevelCurve}
+ */
+func GetLevelCurve () LevelCurve {
+	levelCurveOnce.Do(func () {
+		// fallback to the default curve if the config file is broken
+		levelCurve = LevelCurve {
+			MaxLevel: 45,
+			BaseEXP: 10,
+			GrowthRate: 1.12,
+			SkillPointsPerLevel: 1,
+			ScorePerEXP: 1,
+		}
+		jsonFile, err := os.Open("./src/config/level.json")
+		if (err != nil) {
+			log.Print(err)
+			return
+		}
+		defer jsonFile.Close()
+		byteValue, _ := ioutil.ReadAll(jsonFile)
+		if err = json.Unmarshal(byteValue, &levelCurve); err != nil {
+			log.Print(err)
+		}
+	})
+	return levelCurve
+}
+
+/**
+ * <LevelCurve>.TotalEXP:
+ * The function in LevelCurve to get the total EXP needed to reach the level.
+ *
+ * @param {int} level																					- the target level
+ *
+ * @return {int}
+ */
+func (c LevelCurve) TotalEXP (level int) int {
+	var total = 0.0
+	for l := 1; l < level; l++ {
+		total += math.Floor(c.BaseEXP * math.Pow(c.GrowthRate, float64(l - 1)))
+	}
+	return int(total)
+}
+
+/**
+ * <LevelCurve>.LevelOf:
+ * The function in LevelCurve to get the level reached with the total EXP.
+ *
+ * @param {int} exp																						- the total EXP
+ *
+ * @return {int}
+ */
+func (c LevelCurve) LevelOf (exp int) int {
+	var level = 1
+	for (level < c.MaxLevel) && (exp >= c.TotalEXP(level + 1)) {
+		level++
+	}
+	return level
+}
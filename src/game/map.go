@@ -18,11 +18,13 @@ import (
  * The struct of player diep.
  *
  * @property {string} Name					 												- the name of the player
- * @property {GameObject} 					 												- the game object struct of the diep
+ * @property {*GameObject} 					 												- the game object struct shared with the player
+ * @property {*Player} Player																- the player of the diep
  */
  type Diep struct {
 	Name string
-	GameObject
+	*GameObject
+	Player *Player
 }
 
 /**
@@ -33,12 +35,16 @@ import (
  * @property {int} Damage																		- the damage of the bullet
  * @property {int} Existence																- the existence time of the bullet
  * @property {string} Owner					 												- the name of the owner
+ * @property {int} Rewind																		- the number of ticks to rewind the hit test by the latency of the owner
+ * @property {int} Team																				- the team of the owner, 0 for no team
  */
 type Bullet struct {
 	GameObject
 	Damage int
 	Existence int
 	Owner string
+	Rewind int
+	Team int
 }
 
 /**
@@ -74,13 +80,18 @@ type Stuff struct {
  * TrapAttribute:
  * The struct of trap attribute.
  *
+ * @property {float64} HP																		- the HP of the trap
+ * @property {int} EXP																			- the EXP of the trap
+ * @property {float64} Range																- the radius to search the target diep
  * @property {int} BulletSpeed 					 										- the bullet speed of the trap
  * @property {int} BulletDamage															- the bullet damage of the trap
- * @property {int} BulletReload															- the bullet reload time of the trap
+ * @property {int} BulletReload															- the bullet reload time of the trap in ticks
  * @property {int} BodyDamage																- the body damage of the trap
  */
 type TrapAttribute struct {
-	HP int
+	HP float64
+	EXP int
+	Range float64
 	BulletSpeed int
 	BulletDamage int
 	BulletReload int
@@ -92,22 +103,26 @@ type TrapAttribute struct {
  * The struct of trap.
  *
  * @property {GameObject} 					 												- the game object struct of the trap
+ * @property {int} Type																			- the type number of the trap
  * @property {TrapAttribute} Attr														- the attribute of the trap
+ * @property {float64} reload																- the remaining reload ticks of the trap
  */
 type Trap struct {
 	GameObject
+	Type int
 	Attr TrapAttribute
+	reload float64
 }
 
 /**
  * CollisionDetection:
  * The struct to keep the reference in a collision.
  *
- * @property {*Diep} object_a					 											- the reference of Diep
+ * @property {GameObjectInterface} object_a					 				- the interface of the Diep or Trap
  * @property {GameObjectInterface} object_b					 				- the interface of collider
  */
 type CollisionDetection struct {
-	object_a *Diep
+	object_a GameObjectInterface
 	object_b GameObjectInterface
 }
 
@@ -120,6 +135,7 @@ type CollisionDetection struct {
  * @property {[]*Stuff} Stuffs					 											- the slice of Stuffs on the field
  * @property {[]*Trap} Traps					 												- the slice of Traps on the field
  * @property {[]CollisionDetection} Collisions					 			- the slice of CollisionDetection on the field
+ * @property {MapIndex} Index					 												- the spatial index of all items on the field
  */
 type Map struct {
 	Dieps []*Diep
@@ -127,6 +143,7 @@ type Map struct {
 	Stuffs []*Stuff
 	Traps []*Trap
 	Collisions []CollisionDetection
+	Index MapIndex
 }
 
 /**
@@ -156,22 +173,14 @@ func (g *Game) NewStuff() *Stuff {
 	var new_stuff = Stuff {
 		GameObject: GameObject {
 			Id: uuid,
-			Position: util.Point {
+			Position: util.Vec2 {
 				X: rand.Float64() * g.Field.W,
 				Y: rand.Float64() * g.Field.H,
 			},
 			Mass: 1.0,
 			Radius: 50.0,
-			Velocity: util.VelocityFormat {
-				X: 0.0,
-				Y: 0.0,
-			},
-			Acceleration: util.AccelerationFormat {
-				Up: 0.0,
-				Down: 0.0,
-				Left: 0.0,
-				Right: 0.0,
-			},
+			Velocity: util.Vec2 {},
+			Acceleration: util.Vec2 {},
 		},
 		Type: type_num,
 		Attr: StuffAttribute {
@@ -183,29 +192,190 @@ func (g *Game) NewStuff() *Stuff {
 	return &new_stuff
 }
 
+/**
+ * <*Game>.generateStuff:
+ * The function in Game to keep placing stuffs on the field along the spawn curve until the stuff cap is reached.
+ *
+ * @return {nil}
+ */
 func (g *Game) generateStuff () {
-	for i := 0; i < 50 ; i++ {
+	defer g.routines.Done()
+	for i := 0; i < int(math.Min(50, float64(g.Config.StuffCap))) ; i++ {
 		target := g.NewStuff()
 		if (target == nil) {
 			return
 		}
+		g.ControlLock.Lock()
 		g.MapInfo.Stuffs = append(g.MapInfo.Stuffs, target)
+		g.ControlLock.Unlock()
 		// log.Printf("Generate Stuff %d at Position X: %f, Y: %f, Total stuff: %d\n", target.Type, target.GameObject.Position.X, target.GameObject.Position.Y, len(g.MapInfo.Stuffs))
 	}
 	for {
+		g.ControlLock.Lock()
+		var stuff_number = float64(len(g.MapInfo.Stuffs))
+		var session_number = float64(len(g.Sessions))
+		g.ControlLock.Unlock()
 		// sleep correspond to the number of the stuffs
-		var stepDelay = int32(math.Pow(float64(len(g.MapInfo.Stuffs)), 1.618 / math.Max(float64(len(g.Sessions)), 2) * math.Max(float64(len(g.Sessions) - 1), 1)))
-		time.Sleep(time.Duration(stepDelay) * time.Millisecond)
-		// if the stuff meet the maximum number, the stop generate the stuff
-		if (float64(len(g.MapInfo.Stuffs)) > math.Max(float64(len(g.Sessions)), 1) * 1000 * 1.618 / math.Max(float64(len(g.Sessions)), 2) * math.Max(float64(len(g.Sessions) - 1), 1)) {
+		var stepDelay = int32(math.Pow(stuff_number, g.Config.StuffSpawnCurve / math.Max(session_number, 2) * math.Max(session_number - 1, 1)))
+		if (!g.sleep(time.Duration(stepDelay) * time.Millisecond)) {
 			return
 		}
+		// if the stuff meet the maximum number, then wait for the stuffs to be destroyed
+		if (stuff_number >= float64(g.Config.StuffCap)) {
+			if (!g.sleep(time.Second)) {
+				return
+			}
+			continue
+		}
 		// generate the stuff and append to the map
 		target := g.NewStuff()
 		if (target == nil) {
 			return
 		}
+		g.ControlLock.Lock()
 		g.MapInfo.Stuffs = append(g.MapInfo.Stuffs, target)
+		g.ControlLock.Unlock()
 		// log.Printf("Generate Stuff %d at Position X: %f, Y: %f, Total stuff: %d\n", target.Type, target.GameObject.Position.X, target.GameObject.Position.Y, len(g.MapInfo.Stuffs))
 	}
+}
+
+/**
+ * <*Game>.NewTrap:
+ * The function to new a trap in random position.
+ *
+ * @return {*Trap}
+ */
+func (g *Game) NewTrap() *Trap {
+	// read the trap type through json file
+	jsonFile, err := os.Open("./src/config/trapType.json")
+	if (err != nil) {
+		log.Print(err)
+		return nil
+	}
+	defer jsonFile.Close()
+	byteValue, _ := ioutil.ReadAll(jsonFile)
+	var trapType map[string]TrapAttribute
+	json.Unmarshal([]byte(byteValue), &trapType)
+	if (len(trapType) == 0) {
+		return nil
+	}
+
+	// generate the random type of the trap
+	var type_num = int(rand.Float64() * float64(len(trapType)) + 1)
+	var type_attr = trapType[strconv.Itoa(type_num)]
+
+	uuid, _ := util.NewUUID()
+	var new_trap = Trap {
+		GameObject: GameObject {
+			Id: uuid,
+			Position: util.Vec2 {
+				X: rand.Float64() * g.Field.W,
+				Y: rand.Float64() * g.Field.H,
+			},
+			// the trap is immovable in the collision
+			Mass: 0,
+			Radius: 60.0,
+			Velocity: util.Vec2 {},
+			Acceleration: util.Vec2 {},
+		},
+		Type: type_num,
+		Attr: type_attr,
+		reload: float64(type_attr.BulletReload),
+	}
+	return &new_trap
+}
+
+/**
+ * <*Game>.generateTrap:
+ * The function in Game to keep placing traps on the field until the trap count is reached.
+ *
+ * @return {nil}
+ */
+func (g *Game) generateTrap () {
+	defer g.routines.Done()
+	for {
+		g.ControlLock.Lock()
+		var trap_number = len(g.MapInfo.Traps)
+		g.ControlLock.Unlock()
+		if (trap_number < g.Config.TrapCount) {
+			target := g.NewTrap()
+			if (target == nil) {
+				return
+			}
+			g.ControlLock.Lock()
+			g.MapInfo.Traps = append(g.MapInfo.Traps, target)
+			g.ControlLock.Unlock()
+		}
+		if (!g.sleep(trapSpawnDelay)) {
+			return
+		}
+	}
+}
+
+/**
+ * <*Game>.removeDiep:
+ * The function in Game to remove the diep from the field.
+ *
+ * @param {string} id																				- the game object id of the diep
+ *
+ * @return {nil}
+ */
+func (g *Game) removeDiep (id string) {
+	for i, diep := range g.MapInfo.Dieps {
+		if (diep.GameObject.Id == id) {
+			g.MapInfo.Dieps = append(g.MapInfo.Dieps[:i], g.MapInfo.Dieps[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * <*Game>.removeStuff:
+ * The function in Game to remove the stuff from the field.
+ *
+ * @param {*Stuff} target																		- the target stuff
+ *
+ * @return {nil}
+ */
+func (g *Game) removeStuff (target *Stuff) {
+	for i, stuff := range g.MapInfo.Stuffs {
+		if (stuff == target) {
+			g.MapInfo.Stuffs = append(g.MapInfo.Stuffs[:i], g.MapInfo.Stuffs[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * <*Game>.removeTrap:
+ * The function in Game to remove the trap from the field.
+ *
+ * @param {*Trap} target																		- the target trap
+ *
+ * @return {nil}
+ */
+func (g *Game) removeTrap (target *Trap) {
+	for i, trap := range g.MapInfo.Traps {
+		if (trap == target) {
+			g.MapInfo.Traps = append(g.MapInfo.Traps[:i], g.MapInfo.Traps[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * <*Game>.removeBullet:
+ * The function in Game to remove the bullet from the field.
+ *
+ * @param {*Bullet} target																	- the target bullet
+ *
+ * @return {nil}
+ */
+func (g *Game) removeBullet (target *Bullet) {
+	for i, bullet := range g.MapInfo.Bullets {
+		if (bullet == target) {
+			g.MapInfo.Bullets = append(g.MapInfo.Bullets[:i], g.MapInfo.Bullets[i+1:]...)
+			return
+		}
+	}
 }
\ No newline at end of file
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"log"
+	"net"
+	"time"
+)
+
+/**
+ * <*PlayerSession>.drop:
+ * The function in PlayerSession to tell the client why it is dropped and close the connection.
+ * The session should be removed from the game before. It waits until the writer flushes the queue.
+ *
+ * @param {string} method																			- the method of the last message to client
+ * @param {CommandParams} params															- the params of the last message to client
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) drop (method string, params CommandParams) {
+	if (ps.IsBot()) {
+		ps.Alive = false
+		return
+	}
+	ps.sendClientCommand(PlayerSessionCommand {
+		Method: method,
+		Params: params,
+	})
+	ps.ControlLock.Lock()
+	ps.Alive = false
+	ps.ControlLock.Unlock()
+	if (ps.enqueue(outboundMessage { closeReason: method })) {
+		select {
+			case <- ps.writerDone:
+			case <- time.After(writeTimeout):
+		}
+	}
+	ps.close()
+}
+
+/**
+ * <*PlayerSession>.IP:
+ * The function in PlayerSession to get the ip of the client.
+ *
+ * @return {string}																						- empty for the bots
+ */
+func (ps *PlayerSession) IP () string {
+	if (ps.IsBot()) {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(ps.Socket.RemoteAddr().String())
+	if (err != nil) {
+		return ps.Socket.RemoteAddr().String()
+	}
+	return host
+}
+
+/**
+ * <*Game>.kickWhere:
+ * The function in Game to kick the matched players with the reason.
+ *
+ * @param {func(*PlayerSession) bool} match										- the function to match the target sessions
+ * @param {string} reason																			- the reason to kick the players
+ *
+ * @return {int}																							- the number of the kicked players
+ */
+func (g *Game) kickWhere (match func(*PlayerSession) bool, reason string) int {
+	var removed = g.removeSessions(match)
+	for _, ps := range removed {
+		g.Logger.kickPlayer(ps.Player.Attr.Name, reason)
+		log.Printf("Player %s kicked from game room %s: %s", ps.Player.Attr.Name, g.Name, reason)
+		ps.drop("kicked", CommandParams {
+			"reason": reason,
+		})
+	}
+	return len(removed)
+}
+
+/**
+ * <*Game>.Kick:
+ * The function in Game to kick the player by name.
+ *
+ * @param {string} name																				- the name of the player
+ * @param {string} reason																			- the reason to kick the player
+ *
+ * @return {bool}																							- false if the player is not in the game
+ */
+func (g *Game) Kick (name, reason string) bool {
+	return g.kickWhere(func (ps *PlayerSession) bool {
+		return ps.Player.Attr.Name == name
+	}, reason) > 0
+}
+
+/**
+ * <*Game>.KickIP:
+ * The function in Game to kick all players connected from the ip.
+ *
+ * @param {string} ip																					- the ip of the players
+ * @param {string} reason																			- the reason to kick the players
+ *
+ * @return {int}																							- the number of the kicked players
+ */
+func (g *Game) KickIP (ip, reason string) int {
+	return g.kickWhere(func (ps *PlayerSession) bool {
+		return ps.IP() == ip
+	}, reason)
+}
+
+/**
+ * <*Game>.Broadcast:
+ * The function in Game to send the server message to all players in the game.
+ *
+ * @param {string} message																		- the message to send
+ *
+ * @return {nil}
+ */
+func (g *Game) Broadcast (message string) {
+	g.Logger.broadcastMessage(message)
+	g.sendAll(PlayerSessionCommand {
+		Method: "serverMessage",
+		Params: CommandParams {
+			"message": message,
+		},
+	})
+}
+
+/**
+ * <*Game>.NotifyShutdown:
+ * The function in Game to tell all players the server is shutting down after the countdown.
+ *
+ * @param {int} countdown																			- the remaining seconds before the shutdown
+ *
+ * @return {nil}
+ */
+func (g *Game) NotifyShutdown (countdown int) {
+	g.sendAll(PlayerSessionCommand {
+		Method: "serverShutdown",
+		Params: CommandParams {
+			"countdown": countdown,
+		},
+	})
+}
+
+/**
+ * <*Game>.sendAll:
+ * The function in Game to send the command to all players in the game.
+ *
+ * @param {PlayerSessionCommand} command											- the command to send
+ *
+ * @return {nil}
+ */
+func (g *Game) sendAll (command PlayerSessionCommand) {
+	g.ControlLock.Lock()
+	var sessions = append([]*PlayerSession {}, g.Sessions...)
+	g.ControlLock.Unlock()
+	for _, ps := range sessions {
+		ps.sendClientCommand(command)
+	}
+}
@@ -0,0 +1,214 @@
+package game
+
+import (
+	"github.com/gorilla/websocket"
+	"log"
+	"time"
+)
+
+// define the number of the commands waiting for the writer before the client counts as backed up
+const outboundQueueSize = 64
+// define the number of the commands waiting beyond the full queue before the client is disconnected
+const outboundOverflowSize = 256
+// define the longest time to write one message to client
+const writeTimeout = 2 * time.Second
+// define the longest time the snapshots can keep being coalesced or the commands can keep overflowing before the client is disconnected
+const backedUpTimeout = 3 * time.Second
+
+/**
+ * outboundMessage:
+ * The struct of one message waiting in the outbound queue.
+ *
+ * @property {[]byte} data																		- the encoded message
+ * @property {string} closeReason															- the reason in the close frame, not empty if the connection should be closed after
+ */
+type outboundMessage struct {
+	data []byte
+	closeReason string
+}
+
+/**
+ * <*PlayerSession>.enqueue:
+ * The function in PlayerSession to put the message into the outbound queue without blocking.
+ * The commands are never dropped, the ones beyond the full queue wait in the overflow in order,
+ * and the client is disconnected if the queue stays full over the timeout or the overflow is full.
+ *
+ * @param {outboundMessage} message														- the message to send
+ *
+ * @return {bool}																							- false if the session is closed
+ */
+func (ps *PlayerSession) enqueue (message outboundMessage) bool {
+	select {
+		case <- ps.closed:
+			return false
+		default:
+	}
+	var now = time.Now()
+	ps.ControlLock.Lock()
+	// keep the order behind the commands already waiting in the overflow
+	if (len(ps.overflow) == 0) {
+		select {
+			case ps.outbound <- message:
+				ps.ControlLock.Unlock()
+				return true
+			default:
+		}
+	}
+	if (ps.queueFullSince.IsZero()) {
+		ps.queueFullSince = now
+	}
+	var backed_up = (now.Sub(ps.queueFullSince) > backedUpTimeout) || (len(ps.overflow) >= outboundOverflowSize)
+	if (!backed_up) {
+		ps.overflow = append(ps.overflow, message)
+	}
+	ps.ControlLock.Unlock()
+	if (backed_up) {
+		log.Printf("Player %s backed up, outbound queue full over %v", ps.Player.Attr.Name, backedUpTimeout)
+		ps.close()
+		return false
+	}
+	return true
+}
+
+/**
+ * <*PlayerSession>.refillOutbound:
+ * The function in PlayerSession to move the commands waiting in the overflow into the queue the writer has drained.
+ * The client is no longer backed up once the overflow is empty.
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) refillOutbound () {
+	ps.ControlLock.Lock()
+	defer ps.ControlLock.Unlock()
+	for (len(ps.overflow) > 0) {
+		select {
+			case ps.outbound <- ps.overflow[0]:
+				ps.overflow = ps.overflow[1:]
+			default:
+				// the queue is full again, the rest waits for the next drain
+				return
+		}
+	}
+	ps.overflow = nil
+	ps.queueFullSince = time.Time {}
+}
+
+/**
+ * <*PlayerSession>.queueSnapshot:
+ * The function in PlayerSession to replace the snapshot waiting for the writer with the newer one.
+ * The snapshots are coalesced instead of queued, and the client is disconnected if it stays backed up.
+ *
+ * @param {[]byte} data																				- the encoded snapshot
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) queueSnapshot (data []byte) {
+	select {
+		case <- ps.closed:
+			return
+		default:
+	}
+	var now = time.Now()
+	ps.ControlLock.Lock()
+	if (ps.pendingSnapshot == nil) {
+		ps.backedUpSince = time.Time {}
+	} else if (ps.backedUpSince.IsZero()) {
+		ps.backedUpSince = now
+	}
+	ps.pendingSnapshot = data
+	var backed_up = !ps.backedUpSince.IsZero() && now.Sub(ps.backedUpSince) > backedUpTimeout
+	ps.ControlLock.Unlock()
+	if (backed_up) {
+		log.Printf("Player %s backed up, snapshots not sent in %v", ps.Player.Attr.Name, backedUpTimeout)
+		ps.close()
+		return
+	}
+	// wake up the writer if it is waiting
+	select {
+		case ps.wake <- true:
+		default:
+	}
+}
+
+/**
+ * <*PlayerSession>.takeSnapshot:
+ * The function in PlayerSession to take the snapshot waiting for the writer.
+ *
+ * @return {[]byte}																						- nil if no snapshot is waiting
+ */
+func (ps *PlayerSession) takeSnapshot () []byte {
+	ps.ControlLock.Lock()
+	defer ps.ControlLock.Unlock()
+	var data = ps.pendingSnapshot
+	ps.pendingSnapshot = nil
+	// the writer has caught up with the snapshots
+	ps.backedUpSince = time.Time {}
+	return data
+}
+
+/**
+ * <*PlayerSession>.writer:
+ * The function in PlayerSession to be the only routine writing messages to client.
+ * It stops when the session is closed, a write fails or a close message is written.
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) writer () {
+	defer close(ps.writerDone)
+	defer ps.close()
+	for {
+		select {
+			case message := <- ps.outbound:
+				ps.refillOutbound()
+				if (message.closeReason != "") {
+					ps.Socket.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, message.closeReason), time.Now().Add(writeTimeout))
+					return
+				}
+				if err := ps.writeMessage(message.data); err != nil {
+					return
+				}
+			case <- ps.wake:
+				if data := ps.takeSnapshot(); data != nil {
+					if err := ps.writeMessage(data); err != nil {
+						return
+					}
+				}
+			case <- ps.closed:
+				return
+		}
+	}
+}
+
+/**
+ * <*PlayerSession>.writeMessage:
+ * The function in PlayerSession to write the encoded message to client in the frame type of the codec.
+ * Only the writer routine should call it.
+ *
+ * @param {[]byte} message																		- the encoded message
+ *
+ * @return {error}
+ */
+func (ps *PlayerSession) writeMessage (message []byte) error {
+	ps.Socket.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := ps.Socket.WriteMessage(ps.Codec.MessageType(), message)
+	if (err != nil) {
+		log.Printf("Player %s write failed: %v", ps.Player.Attr.Name, err)
+	}
+	return err
+}
+
+/**
+ * <*PlayerSession>.close:
+ * The function in PlayerSession to stop the writer and close the connection, it is safe to call many times.
+ * The receiver and ping routines find the lost connection and remove the session from the game.
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) close () {
+	ps.closeOnce.Do(func () {
+		close(ps.closed)
+		if (ps.Socket != nil) {
+			ps.Socket.Close()
+		}
+	})
+}
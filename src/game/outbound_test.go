@@ -0,0 +1,141 @@
+package game
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+/**
+ * <game>.newOutboundSession:
+ * The function to get the session with the outbound queue and no writer, so the queue fills up.
+ *
+ * @return {*PlayerSession}
+ */
+func newOutboundSession () *PlayerSession {
+	return &PlayerSession {
+		Player: &Player {
+			Attr: PlayerAttribute { Name: "slow" },
+		},
+		outbound: make (chan outboundMessage, outboundQueueSize),
+		wake: make (chan bool, 1),
+		closed: make (chan bool),
+		writerDone: make (chan bool),
+	}
+}
+
+/**
+ * <game>.isClosed:
+ * The function to check if the session is closed.
+ *
+ * @param {*PlayerSession} ps																	- the session
+ *
+ * @return {bool}
+ */
+func isClosed (ps *PlayerSession) bool {
+	select {
+		case <- ps.closed:
+			return true
+		default:
+			return false
+	}
+}
+
+/**
+ * <game>.TestEnqueueKeepsCommandsInOrder:
+ * The test to check that the commands beyond the full queue are kept and reach the writer in order after it drains.
+ */
+func TestEnqueueKeepsCommandsInOrder (t *testing.T) {
+	var ps = newOutboundSession()
+	var total = outboundQueueSize + 10
+	for i := 0; i < total; i++ {
+		if (!ps.enqueue(outboundMessage { data: []byte(strconv.Itoa(i)) })) {
+			t.Fatalf("command %d not queued", i)
+		}
+	}
+	if (isClosed(ps)) || (len(ps.overflow) != 10) || (ps.queueFullSince.IsZero()) {
+		t.Fatalf("closed %v, overflow %d, full since %v after %d commands", isClosed(ps), len(ps.overflow), ps.queueFullSince, total)
+	}
+	// drain the queue as the writer does
+	for i := 0; i < total; i++ {
+		var message = <- ps.outbound
+		ps.refillOutbound()
+		if (string(message.data) != strconv.Itoa(i)) {
+			t.Fatalf("command %s written at %d", message.data, i)
+		}
+	}
+	if (len(ps.overflow) != 0) || (!ps.queueFullSince.IsZero()) {
+		t.Errorf("overflow %d, full since %v after the queue drained", len(ps.overflow), ps.queueFullSince)
+	}
+}
+
+/**
+ * <game>.TestEnqueueDisconnectsBackedUpClient:
+ * The test to check that the client is disconnected when the commands keep overflowing over the timeout or fill the overflow.
+ */
+func TestEnqueueDisconnectsBackedUpClient (t *testing.T) {
+	var ps = newOutboundSession()
+	for i := 0; i <= outboundQueueSize; i++ {
+		ps.enqueue(outboundMessage { data: []byte("command") })
+	}
+	ps.queueFullSince = time.Now().Add(-backedUpTimeout - time.Second)
+	if (ps.enqueue(outboundMessage { data: []byte("late") })) || (!isClosed(ps)) {
+		t.Error("the client backed up over the timeout is still connected")
+	}
+	ps = newOutboundSession()
+	for i := 0; i < outboundQueueSize + outboundOverflowSize; i++ {
+		if (!ps.enqueue(outboundMessage { data: []byte("command") })) {
+			t.Fatalf("command %d not queued", i)
+		}
+	}
+	if (ps.enqueue(outboundMessage { data: []byte("one too many") })) || (!isClosed(ps)) {
+		t.Error("the client with the full overflow is still connected")
+	}
+}
+
+/**
+ * <game>.TestEnqueueNotBackedUpAfterDrain:
+ * The test to check that the client which filled the queue once and caught up is not disconnected by the next full queue.
+ */
+func TestEnqueueNotBackedUpAfterDrain (t *testing.T) {
+	var ps = newOutboundSession()
+	for i := 0; i <= outboundQueueSize; i++ {
+		ps.enqueue(outboundMessage { data: []byte("command") })
+	}
+	// the queue filled longer than the timeout ago, then the writer drained it
+	ps.queueFullSince = time.Now().Add(-backedUpTimeout - time.Second)
+	for (len(ps.outbound) > 0) {
+		<- ps.outbound
+		ps.refillOutbound()
+	}
+	for i := 0; i <= outboundQueueSize; i++ {
+		ps.enqueue(outboundMessage { data: []byte("command") })
+	}
+	if (isClosed(ps)) || (time.Since(ps.queueFullSince) > time.Second) {
+		t.Errorf("closed %v, full since %v after the queue drained and filled again", isClosed(ps), ps.queueFullSince)
+	}
+}
+
+/**
+ * <game>.TestQueueSnapshotCoalesces:
+ * The test to check that only the latest snapshot waits for the writer, and the client is disconnected
+ * only if the snapshots are not taken over the timeout.
+ */
+func TestQueueSnapshotCoalesces (t *testing.T) {
+	var ps = newOutboundSession()
+	ps.queueSnapshot([]byte("first"))
+	ps.queueSnapshot([]byte("second"))
+	if data := ps.takeSnapshot(); string(data) != "second" {
+		t.Errorf("writer took %q, want the latest", data)
+	}
+	if (!ps.backedUpSince.IsZero()) {
+		t.Error("still backed up after the writer took the snapshot")
+	}
+	ps.queueSnapshot([]byte("third"))
+	ps.queueSnapshot([]byte("fourth"))
+	ps.backedUpSince = time.Now().Add(-backedUpTimeout - time.Second)
+	ps.queueSnapshot([]byte("fifth"))
+	if (!isClosed(ps)) {
+		t.Error("the client not taking the snapshots over the timeout is still connected")
+	}
+}
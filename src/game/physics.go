@@ -0,0 +1,68 @@
+package game
+
+import (
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the bounciness of the collision, 0 for inelastic and 1 for elastic
+const restitution = 0.4
+// define the extra velocity change along the collision normal of unit mass
+const knockback = 4.0
+
+/**
+ * <game>.inverseMass:
+ * The function to get the inverse mass of the game object, the object with non-positive mass is immovable.
+ *
+ * @param {*GameObject} object																- the target game object
+ *
+ * @return {float64}
+ */
+func inverseMass (object *GameObject) float64 {
+	if (object.Mass <= 0) {
+		return 0
+	}
+	return 1.0 / object.Mass
+}
+
+/**
+ * <*Game>.resolveCollision:
+ * The function in Game to apply the impulse-based response between two overlapping circles.
+ * The circles are pushed apart along the collision normal in inverse proportion to their mass,
+ * then the momentum along the normal is exchanged and an extra knockback impulse is applied.
+ *
+ * @param {*GameObject} a																			- the first game object
+ * @param {*GameObject} b																			- the second game object
+ *
+ * @return {nil}
+ */
+func (g *Game) resolveCollision (a, b *GameObject) {
+	var inverse_mass_a = inverseMass(a)
+	var inverse_mass_b = inverseMass(b)
+	var inverse_mass_sum = inverse_mass_a + inverse_mass_b
+	if (inverse_mass_sum == 0) {
+		return
+	}
+	// compute the collision normal from a to b
+	var diff = b.Position.Sub(a.Position)
+	var distance = diff.Length()
+	var normal = util.Vec2 { X: 1 }
+	if (distance > 0) {
+		normal = diff.Scale(1 / distance)
+	}
+	var overlap = a.Radius + b.Radius - distance
+	if (overlap < 0) {
+		return
+	}
+	// push the two circles apart
+	var field_max = util.Vec2 { X: g.Field.W, Y: g.Field.H }
+	a.Position = a.Position.Sub(normal.Scale(overlap * inverse_mass_a / inverse_mass_sum)).Clamp(util.Vec2 {}, field_max)
+	b.Position = b.Position.Add(normal.Scale(overlap * inverse_mass_b / inverse_mass_sum)).Clamp(util.Vec2 {}, field_max)
+	// exchange the momentum along the normal if the two circles are approaching
+	var impulse = knockback
+	var relative_velocity = b.Velocity.Sub(a.Velocity).Dot(normal)
+	if (relative_velocity < 0) {
+		impulse += -(1 + restitution) * relative_velocity / inverse_mass_sum
+	}
+	a.Velocity = a.Velocity.Sub(normal.Scale(impulse * inverse_mass_a))
+	b.Velocity = b.Velocity.Add(normal.Scale(impulse * inverse_mass_b))
+}
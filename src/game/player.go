@@ -13,8 +13,10 @@ import (
  * @property {int} Score																			- the total score of the player
  * @property {int} Level																			- the level of the player
  * @property {int} EXP																				- the current EXP of the player
+ * @property {int} SkillPoint																	- the unspent skill points of the player
+ * @property {string} Class																		- the tank class of the player
  * @property {float64} HP																			- the current HP of the player
- * @property {int} ShootCD																		- the shoot cd time counter
+ * @property {float64} ShootCD																	- the shoot cd time counter in ticks
  */
 type PlayerAttribute struct {
 	Name string
@@ -22,6 +24,8 @@ type PlayerAttribute struct {
 	Score int
 	Level int
 	EXP int
+	SkillPoint int
+	Class string
 	HP float64
 	ShootCD float64
 }
@@ -57,21 +61,35 @@ type PlayerStatus struct {
  * @property {GameObject} 					 													- the game object struct of the player
  * @property {PlayerAttribute} Attr														- the struct of the player attribute
  * @property {PlayerStatus} Status														- the struct of the player status
+ * @property {[]float64} reloads															- the remaining reload ticks of every barrel
+ * @property {int} Team																				- the team of the player, 0 if the game mode has no team
  */
 type Player struct {
 	GameObject
 	Attr PlayerAttribute
 	Status PlayerStatus
+	reloads []float64
+	Team int
 }
 
 /**
  * <*Player>.GainEXP:
- * The function in Player to gain exp and check evaluation.
+ * The function in Player to gain exp and score, and level up if the exp crosses the thresholds.
  *
  * @param {int} exp					 																	- the amount of the exp
  *
- * @return {nil}
+ * @return {int}																							- the number of the levels gained
  */
-func (p *Player) GainEXP(exp int) {
+func (p *Player) GainEXP(exp int) int {
+	var curve = GetLevelCurve()
 	p.Attr.EXP += exp
+	p.Attr.Score += exp * curve.ScorePerEXP
+	var level = curve.LevelOf(p.Attr.EXP)
+	if (level <= p.Attr.Level) {
+		return 0
+	}
+	var gained = level - p.Attr.Level
+	p.Attr.Level = level
+	p.Attr.SkillPoint += gained * curve.SkillPointsPerLevel
+	return gained
 }
\ No newline at end of file
@@ -1,30 +1,27 @@
 package game
 
 import (
-	"encoding/json"
 	"github.com/gorilla/websocket"
 	"time"
 	"log"
-	"math"
 	"sync"
 	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
 	// "sort"
 )
 
+// define the number of the recent views kept as the delta baselines
+const viewHistory = 32
+
 /**
  * PlayerView:
- * The struct to keep all instance on screen. It will be re-compute in every frame.
+ * The struct to keep all instance on screen at one tick. It will be re-compute in every frame.
  *
- * @property {[]*GameObject} Dieps 			- all dieps in player views
- * @property {[]*GameObject} Stuffs			- all stuffs in player views
- * @property {[]*GameObject} Bullets		- all bullets in player views
- * @property {[]*GameObject} Traps			- all traps in player views
+ * @property {uint64} Tick 							- the simulation tick of the view
+ * @property {map[uint32]viewEntity} Entities	- all items in player views by network id
  */
 type PlayerView struct {
-	Dieps  []*GameObject // deips in view
-	Stuffs []*GameObject // stuffs in view
-	Bullets []*GameObject // bullet in view
-	Traps []*GameObject // trap in view
+	Tick uint64
+	Entities map[uint32]viewEntity
 }
 
 /**
@@ -35,20 +32,60 @@ type PlayerView struct {
  * @property {*Game} Game 							- game room instance
  * @property {chan bool} MBus 					- the message channel between ping routine and all other routines
  * @property {bool} Alive 							- the status of the connection
+ * @property {bool} Dead								- whether the player is dead and waiting for respawn, the connection stays
  * @property {*Player} Player						- the player instance
- * @property {*PlayerView} View					- the view instance
+ * @property {[viewHistory]PlayerView} views	- the recent views, the last one is the current view
+ * @property {int} viewIndex						- the index of the current view in views
+ * @property {uint64} ackTick						- the tick of the last snapshot acknowledged by client
  * @property {util.MoveDirection} Moving			- the current moving direction of player
+ * @property {[]playerInput} inputs			- the inputs waiting for the next tick
+ * @property {uint32} inputSeq						- the largest input sequence number received from client
+ * @property {uint32} processedSeq				- the sequence number of the last input applied in the game
+ * @property {float64} processedTime			- the client timestamp of the last input applied in the game
+ * @property {time.Duration} rtt					- the smoothed round trip time measured by ping
+ * @property {BotBehavior} bot						- the behavior driving the session, nil for the real players
  * @property {sync.Mutex} ControlLock		- the mutex lock to prevent from data race in routines
+ * @property {string} ResumeToken				- the token to reattach the player after a lost connection
+ * @property {Codec} Codec							- the codec of the messages between server and client
+ * @property {chan outboundMessage} outbound	- the queue of the commands waiting for the writer
+ * @property {[]byte} pendingSnapshot		- the latest snapshot waiting for the writer
+ * @property {time.Time} backedUpSince		- the time when the snapshots start being coalesced
+ * @property {[]outboundMessage} overflow	- the commands waiting in order beyond the full outbound queue
+ * @property {time.Time} queueFullSince	- the time when the commands start overflowing the outbound queue
+ * @property {chan bool} wake						- the channel to wake up the writer for the snapshot
+ * @property {chan bool} closed					- the channel closed when the session is closed
+ * @property {chan bool} writerDone			- the channel closed when the writer stops
+ * @property {sync.Once} closeOnce				- the guard to close the session once
  */
 type PlayerSession struct {
 	Socket *websocket.Conn
 	Game *Game
 	MBus chan bool
 	Alive bool
+	Dead bool
 	Player *Player // player
-	View PlayerView
+	views [viewHistory]PlayerView
+	viewIndex int
+	ackTick uint64
 	Moving util.MoveDirection
+	inputs []playerInput
+	inputSeq uint32
+	processedSeq uint32
+	processedTime float64
+	rtt time.Duration
+	bot BotBehavior
 	ControlLock sync.Mutex
+	ResumeToken string
+	Codec Codec
+	outbound chan outboundMessage
+	pendingSnapshot []byte
+	backedUpSince time.Time
+	overflow []outboundMessage
+	queueFullSince time.Time
+	wake chan bool
+	closed chan bool
+	writerDone chan bool
+	closeOnce sync.Once
 }
 
 /**
@@ -84,29 +121,12 @@ func (ps *PlayerSession) receiver() {
 		if (err != nil) {
 			break
 		}
-		var player_command PlayerSessionCommand = PlayerSessionCommand{}
-		err = json.Unmarshal(command, &player_command)
-
-		ps.serveCommand(player_command)
-	}
-}
-
-/**
- * <*PlayerSession>.loop:
- * The function in PlayerSession to keep sending the player information in every frame.
- *
- * @return {nil}
- */
-func (ps *PlayerSession) loop() {
-	var stepDelay int32 = int32(1000 / ps.Game.Framerate)
-	for {
-		ps.ControlLock.Lock()
-		if (!ps.Alive) {
-			return
+		player_command, err := ps.Codec.DecodeCommand(command)
+		if (err != nil) {
+			ps.Game.Logger.InvalidArg("command")
+			continue
 		}
-		ps.ControlLock.Unlock()
-		time.Sleep(time.Duration(stepDelay) * time.Millisecond)
-		ps.sendPlayerState()
+		ps.serveCommand(player_command)
 	}
 }
 
@@ -136,12 +156,19 @@ func (ps *PlayerSession) ping() {
 		// catch channel message
 		select {
 			case  <- timeout:
+				// the session is already dropped by the game
+				if (!ps.Alive) {
+					return
+				}
 				if (!alive) {
 					// log loose connection message
 					ps.Game.Logger.looseConnection(ps.Player.Attr.Name, ps.Game.Name, int(len(ps.Game.Sessions)) - 1)
 					log.Printf("Player %s disconnect", ps.Player.Attr.Name)
-					ps.Game.Disconnect(ps.Player.Attr.Name)
-					ps.Socket.Close()
+					// keep the diep for resuming if possible
+					if (!ps.Game.suspendSession(ps)) {
+						ps.Game.Disconnect(ps.Player.Attr.Name)
+					}
+					ps.close()
 					// lock the Alive attr in player session
 					ps.ControlLock.Lock()
 					ps.Alive = false
@@ -170,71 +197,72 @@ func (ps *PlayerSession) ping() {
  */
 func (ps *PlayerSession) serveCommand(command PlayerSessionCommand) {
 	// send the connection status through channel
-	ps.MBus <- true
+	select {
+		case ps.MBus <- true:
+		default:
+	}
+	// keep the inputs to apply in the next tick, the sequence is checked with the pending inputs
+	if (isInputMethod(command.Method)) {
+		ps.queueInput(command)
+		return
+	}
+	// drop the out-of-order and duplicate commands
+	if (!ps.acceptSeq(command)) {
+		return
+	}
 	// define player method with correspond action
 	switch command.Method {
-		case "moveUp":
-			ps.Moving.Up = command.Params["value"].(bool)
-			break
-		case "moveDown":
-			ps.Moving.Down = command.Params["value"].(bool)
+		case "upgradeClass":
+			class, _ := command.Params["class"].(string)
+			ps.UpgradeClass(class)
 			break
-		case "moveLeft":
-			ps.Moving.Left = command.Params["value"].(bool)
+		case "evaluation":
+			type_str, _ := command.Params["type"].(string)
+			ps.Evaluation(type_str)
 			break
-		case "moveRight":
-			ps.Moving.Right = command.Params["value"].(bool)
+		case "respawn":
+			ps.Respawn()
 			break
-		case "shoot":
-			ps.Shoot(command.Params["angle"].(float64), command.Params["number"].(int))
+		case "pong":
+			sent, ok := command.Params["time"].(float64)
+			if (ok) {
+				ps.ControlLock.Lock()
+				ps.updateRTT(sent)
+				ps.ControlLock.Unlock()
+			}
 			break
-		case "evaluation":
-			ps.Evaluation(command.Params["type"].(string))
+		case "ack":
+			tick, _ := command.Params["tick"].(float64)
+			ps.ControlLock.Lock()
+			if (uint64(tick) > ps.ackTick) {
+				ps.ackTick = uint64(tick)
+			}
+			ps.ControlLock.Unlock()
 			break
 	}
 }
 
 /**
  * <*PlayerSession>.sendClientCommand:
- * The function in PlayerSession to send message to client in PlayerSessionCommand format.
+ * The function in PlayerSession to queue message to client in PlayerSessionCommand format.
  *
  * @param {PlayerSessionCommand} command	- the message sening to client
  *
  * @return {nil}
  */
 func (ps *PlayerSession) sendClientCommand(command PlayerSessionCommand) {
-	ps.ControlLock.Lock()
-	message_b, _ := json.Marshal(command)
-	err := ps.Socket.WriteMessage(websocket.TextMessage, message_b)
-	ps.ControlLock.Unlock()
+	// the bots have no client to send to
+	if (ps.IsBot()) {
+		return
+	}
+	message_b, err := ps.Codec.EncodeCommand(command)
 	if (err != nil) {
-		ps.Socket.Close()
+		log.Println("[Error]: Encode command:", err)
+		return
 	}
-}
-
-/**
- * <*PlayerSession>.sendPlayerState:
- * The function in PlayerSession to send player status to client in PlayerSessionCommand format.
- *
- * @return {nil}
- */
-func (ps *PlayerSession) sendPlayerState() {
-	ps.ControlLock.Lock()
-	// update the player view of all diep
-	ps.updateView()
-	ps.ControlLock.Unlock()
-	// send all diep position to client
-	ps.sendClientCommand(PlayerSessionCommand {
-		Method: "playerSession",
-		Params: CommandParams {
-			"player": ps.Player,
-			"dieps": ps.View.Dieps,
-			"stuffs": ps.View.Stuffs,
-			"traps": ps.View.Traps,
-			"bullets": ps.View.Bullets,
-		},
+	ps.enqueue(outboundMessage {
+		data: message_b,
 	})
-	// log.Println("test")
 }
 
 /**
@@ -246,66 +274,106 @@ func (ps *PlayerSession) sendPlayerState() {
 func (ps *PlayerSession) sendPingMsg() {
 	ps.sendClientCommand(PlayerSessionCommand {
 		Method: "ping",
-		Params: CommandParams {},
+		Params: CommandParams {
+			// the client echoes the time in pong to measure the round trip time
+			"time": float64(time.Now().UnixNano()) / 1e6,
+		},
 	})
 }
 
 /**
  * <*PlayerSession>.updateView:
  * The function in PlayerSession to compute the view information in every frame.
+ * The view is written to the next slot of the view history, so the older views stay as the delta baselines.
+ * If the tick has not advanced, the current slot is written again since nothing has changed.
  *
  * @return {nil}
  */
 func (ps *PlayerSession) updateView () {
-	// get the view width and height
-	var vwL = math.Max(ps.Player.GameObject.Position.X - 1920 / 2, 0)
-	var vwU = math.Min(ps.Player.GameObject.Position.X + 1920 / 2, ps.Game.Field.W)
-	var vhL = math.Max(ps.Player.GameObject.Position.Y - 1080 / 2, 0)
-	var vhU = math.Min(ps.Player.GameObject.Position.Y + 1080 / 2, ps.Game.Field.H)
-	// empty all slice in player session
-	ps.View.Dieps = []*GameObject {}
-	ps.View.Stuffs = []*GameObject {}
-	ps.View.Traps = []*GameObject {}
-	ps.View.Bullets = []*GameObject {}
-	// loop the map info and append the diep/stuff/trap in view
-	for _, diep := range ps.Game.MapInfo.Dieps {
-		if (diep.GameObject.Position.X >= vwL) && (diep.GameObject.Position.X <= vwU) &&
-			(diep.GameObject.Position.Y >= vhL) && (diep.GameObject.Position.Y <= vhU) {
-			ps.View.Dieps = append(ps.View.Dieps, &diep.GameObject)
-		}
+	// get the view box around the player
+	var half_view = util.Vec2 { X: 1920 / 2, Y: 1080 / 2 }
+	var field = util.Vec2 { X: ps.Game.Field.W, Y: ps.Game.Field.H }
+	var view_min = ps.Player.GameObject.Position.Sub(half_view).Clamp(util.Vec2 {}, field)
+	var view_max = ps.Player.GameObject.Position.Add(half_view).Clamp(util.Vec2 {}, field)
+	// take the slot of the oldest view and empty it
+	if (ps.views[ps.viewIndex].Entities == nil) || (ps.views[ps.viewIndex].Tick != ps.Game.Tick) {
+		ps.viewIndex = (ps.viewIndex + 1) % viewHistory
 	}
-	for _, stuff := range ps.Game.MapInfo.Stuffs {
-		if (stuff.GameObject.Position.X >= vwL) && (stuff.GameObject.Position.X <= vwU) &&
-			(stuff.GameObject.Position.Y >= vhL) && (stuff.GameObject.Position.Y <= vhU) {
-			ps.View.Stuffs = append(ps.View.Stuffs, &stuff.GameObject)
-		}
+	var view = &ps.views[ps.viewIndex]
+	view.Tick = ps.Game.Tick
+	if (view.Entities == nil) {
+		view.Entities = map[uint32]viewEntity {}
+	}
+	for id := range view.Entities {
+		delete(view.Entities, id)
+	}
+	// query the spatial index and add the diep/stuff/trap/bullet in view
+	addInView(view, entityDiep, ps.Game.MapInfo.Index.Dieps, view_min, view_max)
+	addInView(view, entityStuff, ps.Game.MapInfo.Index.Stuffs, view_min, view_max)
+	addInView(view, entityTrap, ps.Game.MapInfo.Index.Traps, view_min, view_max)
+	addInView(view, entityBullet, ps.Game.MapInfo.Index.Bullets, view_min, view_max)
+}
+
+/**
+ * <*PlayerSession>.findView:
+ * The function in PlayerSession to find the view sent at the tick in the view history.
+ *
+ * @param {uint64} tick									- the tick of the view
+ *
+ * @return {*PlayerView}								- nil if the view is not kept anymore
+ */
+func (ps *PlayerSession) findView (tick uint64) *PlayerView {
+	if (tick == 0) {
+		return nil
 	}
-	for _, trap := range ps.Game.MapInfo.Traps {
-		if (trap.GameObject.Position.X >= vwL) && (trap.GameObject.Position.X <= vwU) &&
-			(trap.GameObject.Position.Y >= vhL) && (trap.GameObject.Position.Y <= vhU) {
-			ps.View.Traps = append(ps.View.Traps, &trap.GameObject)
+	for i := range ps.views {
+		if (ps.views[i].Entities != nil) && (ps.views[i].Tick == tick) {
+			return &ps.views[i]
 		}
 	}
-	for _, bullet := range ps.Game.MapInfo.Bullets {
-		if (bullet.GameObject.Position.X >= vwL) && (bullet.GameObject.Position.X <= vwU) &&
-			(bullet.GameObject.Position.Y >= vhL) && (bullet.GameObject.Position.Y <= vhU) {
-			ps.View.Bullets = append(ps.View.Bullets, &bullet.GameObject)
+	return nil
+}
+
+/**
+ * <game>.addInView:
+ * The function to add the objects whose center is inside the view rectangle to the view.
+ *
+ * @param {*PlayerView} view						- the view to add to
+ * @param {uint8} kind									- the kind of the objects
+ * @param {*SpatialHash} index					- the spatial index to query
+ * @param {util.Vec2} view_min					- the top-left corner of the view
+ * @param {util.Vec2} view_max					- the bottom-right corner of the view
+ *
+ * @return {nil}
+ */
+func addInView (view *PlayerView, kind uint8, index *SpatialHash, view_min, view_max util.Vec2) {
+	for _, object := range index.QueryRect(view_min, view_max) {
+		var game_object = object.GetGameObject()
+		if (game_object.Position.Clamp(view_min, view_max) == game_object.Position) {
+			view.Entities[game_object.NetId] = newViewEntity(kind, object)
 		}
 	}
 }
 
 /**
  * <*PlayerSession>.Shoot:
- * The function in PlayerSession to shot.
+ * The function in PlayerSession to shot from every reloaded barrel of the tank class.
+ * The caller should hold the game lock and the session lock.
  *
  * @param {float64} angle								- the angle of the bullet shoot direction
- * @param {int} number									- the bullet number in this shoot
  *
  * @return {nil}
  */
-func (ps *PlayerSession) Shoot (angle float64, number int) {
-	// if there is cd time, then refuse shoot
-	if (ps.Player.Attr.ShootCD >= 0) {
+func (ps *PlayerSession) Shoot (angle float64) {
+	var bullets = ps.Player.fireBarrels(angle, ps.Game.Framerate)
+	// test the bullets against the world the player saw
+	var rewind = ps.rewindTicks()
+	for _, bullet := range bullets {
+		bullet.Rewind = rewind
+	}
+	ps.Game.MapInfo.Bullets = append(ps.Game.MapInfo.Bullets, bullets...)
+	// if all barrels are in cd time, then refuse shoot
+	if (len(bullets) == 0) {
 		ps.sendClientCommand(PlayerSessionCommand {
 			Method: "shoot",
 			Params: CommandParams {
@@ -314,75 +382,96 @@ func (ps *PlayerSession) Shoot (angle float64, number int) {
 		})
 		return
 	}
-	for i := 0; i < number; i++ {
-		var new_bullet Bullet
-		new_bullet.Position.X = ps.Player.Position.X
-		new_bullet.Position.Y = ps.Player.Position.Y
-		new_bullet.Velocity.X = math.Acos(angle / 360 * 2 * math.Pi) * float64(ps.Player.Status.BulletSpeed + 10) / ratio
-		new_bullet.Velocity.Y = math.Asin(angle / 360 * 2 * math.Pi) * float64(ps.Player.Status.BulletSpeed + 10) / ratio
-		new_bullet.Owner = ps.Player.Id
-		new_bullet.Existence = (ps.Player.Status.BulletPenetration - 1) * 40 +  250
-		ps.Game.MapInfo.Bullets = append(ps.Game.MapInfo.Bullets, &new_bullet)
-	}
-	// add shoot cd time
-	ps.Player.Attr.ShootCD += 1000 / math.Log2(1.0 / float64(ps.Player.Status.BulletReload))
 	// log shoot message
-	ps.Game.Logger.shootBullet(ps.Player.Attr.Name, number, angle)
+	ps.Game.Logger.shootBullet(ps.Player.Attr.Name, len(bullets), angle)
+}
+
+/**
+ * <*PlayerSession>.UpgradeClass:
+ * The function in PlayerSession to upgrade the tank class, and reply the result to client.
+ *
+ * @param {string} class								- the name of the target class
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) UpgradeClass (class string) {
+	ps.Game.ControlLock.Lock()
+	var from = ps.Player.Attr.Class
+	err := ps.Player.UpgradeClass(class)
+	var available = ps.Player.AvailableClasses()
+	ps.Game.ControlLock.Unlock()
+	var params = CommandParams {
+		"class": ps.Player.Attr.Class,
+		"classUpgrades": available,
+	}
+	if (err != nil) {
+		params["message"] = err.Error()
+	} else {
+		// log class upgrade message
+		ps.Game.Logger.playerUpgradeClass(ps.Player.Attr.Name, from, class)
+	}
+	params["success"] = (err == nil)
+	ps.sendClientCommand(PlayerSessionCommand {
+		Method: "upgradeClassResult",
+		Params: params,
+	})
+}
+
+/**
+ * <*PlayerSession>.gainEXP:
+ * The function in PlayerSession to give exp to the player and notify the client about the level up.
+ *
+ * @param {int} exp											- the amount of the exp
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) gainEXP (exp int) {
+	var from = ps.Player.Attr.Level
+	if (ps.Player.GainEXP(exp) == 0) {
+		return
+	}
+	// log level up message
+	ps.Game.Logger.playerLevelUp(ps.Player.Attr.Name, from, ps.Player.Attr.Level)
+	ps.sendClientCommand(PlayerSessionCommand {
+		Method: "levelUp",
+		Params: CommandParams {
+			"level": ps.Player.Attr.Level,
+			"exp": ps.Player.Attr.EXP,
+			"nextLevelEXP": GetLevelCurve().TotalEXP(ps.Player.Attr.Level + 1),
+			"skillPoint": ps.Player.Attr.SkillPoint,
+			"classUpgrades": ps.Player.AvailableClasses(),
+		},
+	})
 }
 
 /**
  * <*PlayerSession>.Evaluation:
- * The function in PlayerSession to evaluation player diep.
+ * The function in PlayerSession to evaluation player diep, and reply the result to client.
  *
  * @param {string} type_str							- the chosen attribute of player in this evaluation
  *
  * @return {nil}
  */
 func (ps *PlayerSession) Evaluation (type_str string) {
-	var from = 0
-	var to = 0
-	switch type_str {
-		case "MaxHP":
-			ps.Player.Status.MaxHP++
-			from = ps.Player.Status.MaxHP - 1
-			to = ps.Player.Status.MaxHP
-			break
-		case "HPRegeneration":
-			ps.Player.Status.HPRegeneration++
-			from = ps.Player.Status.HPRegeneration - 1
-			to = ps.Player.Status.HPRegeneration
-			break
-		case "MoveSpeed":
-			ps.Player.Status.MoveSpeed++
-			from = ps.Player.Status.MoveSpeed - 1
-			to = ps.Player.Status.MoveSpeed
-			break
-		case "BulletSpeed":
-			ps.Player.Status.BulletSpeed++
-			from = ps.Player.Status.BulletSpeed - 1
-			to = ps.Player.Status.BulletSpeed
-			break
-		case "BulletPenetration":
-			ps.Player.Status.BulletPenetration++
-			from = ps.Player.Status.BulletPenetration - 1
-			to = ps.Player.Status.BulletPenetration
-			break
-		case "BulletReload":
-			ps.Player.Status.BulletReload++
-			from = ps.Player.Status.BulletReload - 1
-			to = ps.Player.Status.BulletReload
-			break
-		case "BulletDamage":
-			ps.Player.Status.BulletDamage++
-			from = ps.Player.Status.BulletDamage - 1
-			to = ps.Player.Status.BulletDamage
-			break
-		case "BodyDamage":
-			ps.Player.Status.BodyDamage++
-			from = ps.Player.Status.BodyDamage - 1
-			to = ps.Player.Status.BodyDamage
-			break
+	ps.Game.ControlLock.Lock()
+	from, to, err := ps.Player.Evaluate(type_str)
+	var status = ps.Player.Status
+	var skill_point = ps.Player.Attr.SkillPoint
+	ps.Game.ControlLock.Unlock()
+	var params = CommandParams {
+		"type": type_str,
+		"status": status,
+		"skillPoint": skill_point,
+	}
+	if (err != nil) {
+		params["message"] = err.Error()
+	} else {
+		// log evaluation message
+		ps.Game.Logger.playerEvaluation(ps.Player.Attr.Name, type_str, from, to)
 	}
-	// log evaluation message
-	ps.Game.Logger.playerEvaluation(ps.Player.Attr.Name, type_str, from, to)
-}
\ No newline at end of file
+	params["success"] = (err == nil)
+	ps.sendClientCommand(PlayerSessionCommand {
+		Method: "evaluationResult",
+		Params: params,
+	})
+}
@@ -0,0 +1,183 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+)
+
+// define the protocol names negotiated by the "protocol" query
+const JSONProtocol = "json"
+const BinaryProtocol = "binary"
+
+/**
+ * Codec:
+ * The interface to encode the messages to client and decode the messages from client.
+ * All codecs share the PlayerSessionCommand and Snapshot message definitions.
+ */
+type Codec interface {
+	Name() string
+	MessageType() int
+	EncodeCommand(command PlayerSessionCommand) ([]byte, error)
+	DecodeCommand(data []byte) (PlayerSessionCommand, error)
+	EncodeSnapshot(snapshot *Snapshot) ([]byte, error)
+	DecodeSnapshot(data []byte) (*Snapshot, error)
+}
+
+// define the opcode of every command method, the opcode 0 is followed by the method name for the methods not in the table
+var opcodeTable = []string {
+	"",
+	"playerSession",
+	"ping",
+	"pong",
+	"moveUp",
+	"moveDown",
+	"moveLeft",
+	"moveRight",
+	"shoot",
+	"upgradeClass",
+	"evaluation",
+	"levelUp",
+	"evaluationResult",
+	"upgradeClassResult",
+	"playerDead",
+	"resumeToken",
+	"serverMessage",
+	"serverShutdown",
+	"kicked",
+	"roomClosed",
+	"ack",
+	"roundOver",
+	"respawn",
+	"respawnResult",
+}
+
+// keep the reverse lookup of the opcode table
+var opcodeOf = func () map[string]byte {
+	var table = map[string]byte {}
+	for opcode, method := range opcodeTable {
+		if (method != "") {
+			table[method] = byte(opcode)
+		}
+	}
+	return table
+}()
+
+/**
+ * <game>.NewCodec:
+ * The function to get the codec by the protocol name, json if empty.
+ *
+ * @param {string} protocol																		- the name of the protocol
+ *
+ * @return {Codec, error}
+ */
+func NewCodec (protocol string) (Codec, error) {
+	switch (protocol) {
+		case "", JSONProtocol:
+			return JSONCodec {}, nil
+		case BinaryProtocol:
+			return BinaryCodec {}, nil
+	}
+	return nil, errors.New("Unknown protocol " + protocol + "!")
+}
+
+/**
+ * JSONCodec:
+ * The codec to send every message as a json text frame, kept as the fallback protocol.
+ */
+type JSONCodec struct {}
+
+/**
+ * jsonSnapshotCommand:
+ * The struct of the snapshot in the json command format.
+ *
+ * @property {string} Method																	- always "playerSession"
+ * @property {*Snapshot} Params																- the snapshot
+ */
+type jsonSnapshotCommand struct {
+	Method string
+	Params *Snapshot
+}
+
+/**
+ * <JSONCodec>.Name:
+ * The function in JSONCodec to get the protocol name.
+ *
+ * @return {string}
+ */
+func (c JSONCodec) Name () string {
+	return JSONProtocol
+}
+
+/**
+ * <JSONCodec>.MessageType:
+ * The function in JSONCodec to get the websocket frame type.
+ *
+ * @return {int}
+ */
+func (c JSONCodec) MessageType () int {
+	return websocket.TextMessage
+}
+
+/**
+ * <JSONCodec>.EncodeCommand:
+ * The function in JSONCodec to encode the command.
+ *
+ * @param {PlayerSessionCommand} command											- the command to encode
+ *
+ * @return {[]byte, error}
+ */
+func (c JSONCodec) EncodeCommand (command PlayerSessionCommand) ([]byte, error) {
+	return json.Marshal(command)
+}
+
+/**
+ * <JSONCodec>.DecodeCommand:
+ * The function in JSONCodec to decode the command.
+ *
+ * @param {[]byte} data																				- the data to decode
+ *
+ * @return {PlayerSessionCommand, error}
+ */
+func (c JSONCodec) DecodeCommand (data []byte) (PlayerSessionCommand, error) {
+	var command = PlayerSessionCommand {}
+	err := json.Unmarshal(data, &command)
+	if (command.Params == nil) {
+		command.Params = CommandParams {}
+	}
+	return command, err
+}
+
+/**
+ * <JSONCodec>.EncodeSnapshot:
+ * The function in JSONCodec to encode the snapshot as a "playerSession" command.
+ *
+ * @param {*Snapshot} snapshot																- the snapshot to encode
+ *
+ * @return {[]byte, error}
+ */
+func (c JSONCodec) EncodeSnapshot (snapshot *Snapshot) ([]byte, error) {
+	return json.Marshal(jsonSnapshotCommand {
+		Method: "playerSession",
+		Params: snapshot,
+	})
+}
+
+/**
+ * <JSONCodec>.DecodeSnapshot:
+ * The function in JSONCodec to decode the snapshot from a "playerSession" command.
+ *
+ * @param {[]byte} data																				- the data to decode
+ *
+ * @return {*Snapshot, error}
+ */
+func (c JSONCodec) DecodeSnapshot (data []byte) (*Snapshot, error) {
+	var command jsonSnapshotCommand
+	if err := json.Unmarshal(data, &command); err != nil {
+		return nil, err
+	}
+	if (command.Method != "playerSession" || command.Params == nil) {
+		return nil, errors.New("Not a snapshot message!")
+	}
+	return command.Params, nil
+}
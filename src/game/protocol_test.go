@@ -0,0 +1,95 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+/**
+ * <game>.TestNewCodec:
+ * The test to check the codec is picked by the protocol name, json if empty.
+ */
+func TestNewCodec (t *testing.T) {
+	var cases = map[string]string {
+		"": JSONProtocol,
+		JSONProtocol: JSONProtocol,
+		BinaryProtocol: BinaryProtocol,
+	}
+	for protocol, want := range cases {
+		codec, err := NewCodec(protocol)
+		if (err != nil) {
+			t.Fatalf("%q: %v", protocol, err)
+		}
+		if (codec.Name() != want) {
+			t.Errorf("%q picked %s, want %s", protocol, codec.Name(), want)
+		}
+	}
+	if _, err := NewCodec("xml"); err == nil {
+		t.Error("unknown protocol picked a codec")
+	}
+}
+
+/**
+ * <game>.TestJSONSnapshotRoundTrip:
+ * The test to check the full and delta snapshots decode back unchanged.
+ */
+func TestJSONSnapshotRoundTrip (t *testing.T) {
+	for _, delta := range []bool { false, true } {
+		var want = testSnapshot(delta)
+		data, err := JSONCodec {}.EncodeSnapshot(want)
+		if (err != nil) {
+			t.Fatalf("delta %v: encode: %v", delta, err)
+		}
+		got, err := JSONCodec {}.DecodeSnapshot(data)
+		if (err != nil) {
+			t.Fatalf("delta %v: decode: %v", delta, err)
+		}
+		if (!reflect.DeepEqual(got, want)) {
+			t.Errorf("delta %v: decoded as %+v, want %+v", delta, got, want)
+		}
+	}
+}
+
+/**
+ * <game>.TestJSONCommandRoundTrip:
+ * The test to check the commands decode back unchanged, the missing params as empty.
+ */
+func TestJSONCommandRoundTrip (t *testing.T) {
+	var commands = []PlayerSessionCommand {
+		{ Method: "shoot", Params: CommandParams { "angle": 1.5, "seq": 7.0, "time": 123.5 } },
+		{ Method: "customMethod", Params: CommandParams { "text": "hello", "value": true } },
+		{ Method: "respawn", Params: CommandParams {} },
+	}
+	for _, want := range commands {
+		data, err := JSONCodec {}.EncodeCommand(want)
+		if (err != nil) {
+			t.Fatalf("%s: encode: %v", want.Method, err)
+		}
+		got, err := JSONCodec {}.DecodeCommand(data)
+		if (err != nil) {
+			t.Fatalf("%s: decode: %v", want.Method, err)
+		}
+		if (!reflect.DeepEqual(got, want)) {
+			t.Errorf("decoded as %+v, want %+v", got, want)
+		}
+	}
+	got, err := JSONCodec {}.DecodeCommand([]byte(`{"Method":"ping"}`))
+	if (err != nil) || (got.Params == nil) {
+		t.Errorf("command without params decoded as %+v, %v", got, err)
+	}
+}
+
+/**
+ * <game>.TestJSONDecodeMalformed:
+ * The test to check the broken messages and the commands other than the snapshot are rejected.
+ */
+func TestJSONDecodeMalformed (t *testing.T) {
+	if _, err := (JSONCodec {}).DecodeCommand([]byte(`{"Method":"shoot","Params":`)); err == nil {
+		t.Error("truncated command decoded without error")
+	}
+	for _, data := range []string { `{"Method":"playerSession","Params":`, `{"Method":"ping","Params":{}}`, `{"Method":"playerSession"}` } {
+		if _, err := (JSONCodec {}).DecodeSnapshot([]byte(data)); err == nil {
+			t.Errorf("%s decoded as a snapshot", data)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package game
+
+import (
+	"errors"
+	"log"
+	"math"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the distance from the other dieps counted as a safe spawn point
+const safeSpawnDistance = 400
+// define the number of the spawn points tried to find a safe one
+const spawnAttempts = 10
+
+/**
+ * <*PlayerSession>.Playing:
+ * The function in PlayerSession to check if the player is connected and not dead.
+ *
+ * @return {bool}
+ */
+func (ps *PlayerSession) Playing () bool {
+	return ps.Alive && !ps.Dead
+}
+
+/**
+ * <*Game>.safeSpawnPoint:
+ * The function in Game to get the spawn point of the game mode away from the other dieps.
+ * If no point is far enough in the attempts, the one farthest from the dieps is taken.
+ * The caller should hold the game lock.
+ *
+ * @param {*Player} player																		- the spawning player
+ *
+ * @return {util.Vec2}
+ */
+func (g *Game) safeSpawnPoint (player *Player) util.Vec2 {
+	var best util.Vec2
+	var best_distance = -1.0
+	for i := 0; i < spawnAttempts; i++ {
+		var point = g.Mode.SpawnPoint(g, player)
+		var distance = float64(safeSpawnDistance)
+		for _, diep := range g.MapInfo.Dieps {
+			if (diep.Player != player) {
+				distance = math.Min(distance, diep.Position.Sub(point).Length() - diep.Radius)
+			}
+		}
+		if (distance >= safeSpawnDistance) {
+			return point
+		}
+		if (distance > best_distance) {
+			best = point
+			best_distance = distance
+		}
+	}
+	return best
+}
+
+/**
+ * <*Game>.killPlayer:
+ * The function in Game to mark the player dead, count the kill in the game mode and send the death summary.
+ * The dead player keeps the connection and watches the field until respawn. The caller should hold the game lock.
+ *
+ * @param {*PlayerSession} victim															- the session of the dead player
+ * @param {string} killer_id																	- the id of the killer
+ * @param {string} cause																			- the name of the killer if it is not a player
+ *
+ * @return {nil}
+ */
+func (g *Game) killPlayer (victim *PlayerSession, killer_id, cause string) {
+	// log the dead message
+	g.Logger.deadMessage(victim.Player.GameObject.Id, killer_id)
+	var killer *Player
+	var killer_name = cause
+	if ps := g.findSession(killer_id); ps != nil {
+		killer = ps.Player
+		killer_name = ps.Player.Attr.Name
+	}
+	g.Mode.OnKill(g, victim.Player, killer)
+	level, can_respawn := g.Mode.RespawnLevel(g, victim.Player)
+	victim.sendClientCommand(PlayerSessionCommand {
+		Method: "playerDead",
+		Params: CommandParams {
+			"killer": killer_name,
+			"score": victim.Player.Attr.Score,
+			"level": victim.Player.Attr.Level,
+			"timeAlive": time.Since(victim.Player.Attr.CreatedAt).Seconds(),
+			"canRespawn": can_respawn,
+			"respawnLevel": level,
+		},
+	})
+	victim.ControlLock.Lock()
+	victim.Dead = true
+	victim.Moving = util.MoveDirection {}
+	victim.ControlLock.Unlock()
+	// take the diep off the field, the player still sees around
+	g.removeDiep(victim.Player.GameObject.Id)
+}
+
+/**
+ * <*Game>.respawnPlayer:
+ * The function in Game to give the session a new player at the level in the same team and with the same id, and put it on the field.
+ * The caller should hold the game lock.
+ *
+ * @param {*PlayerSession} ps																	- the session to respawn
+ * @param {int} level																					- the level of the new player
+ *
+ * @return {nil}
+ */
+func (g *Game) respawnPlayer (ps *PlayerSession, level int) {
+	g.removeDiep(ps.Player.GameObject.Id)
+	var player = NewPlayer(ps.Player.Attr.Name)
+	// keep the id for the bullets still flying and the lookups by id
+	player.GameObject.Id = ps.Player.GameObject.Id
+	player.Team = ps.Player.Team
+	// set the kept level directly, the gained exp would count as the score of the new life
+	var curve = GetLevelCurve()
+	player.Attr.EXP = curve.TotalEXP(level)
+	var reached = curve.LevelOf(player.Attr.EXP)
+	player.Attr.SkillPoint += (reached - player.Attr.Level) * curve.SkillPointsPerLevel
+	player.Attr.Level = reached
+	ps.ControlLock.Lock()
+	ps.Player = player
+	ps.Dead = false
+	ps.Moving = util.MoveDirection {}
+	ps.ControlLock.Unlock()
+	g.addDiep(player)
+}
+
+/**
+ * <*PlayerSession>.Respawn:
+ * The function in PlayerSession to respawn the dead player if the game mode allows, and reply the result to client.
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) Respawn () {
+	ps.Game.ControlLock.Lock()
+	var err error
+	level, ok := ps.Game.Mode.RespawnLevel(ps.Game, ps.Player)
+	if (!ps.Dead) {
+		err = errors.New("The player is not dead!")
+	} else if (!ok) {
+		err = errors.New("The game mode does not allow respawn!")
+	} else {
+		ps.Game.respawnPlayer(ps, level)
+	}
+	ps.Game.ControlLock.Unlock()
+	var params = CommandParams {}
+	if (err != nil) {
+		params["message"] = err.Error()
+	} else {
+		params["level"] = level
+		log.Printf("Player %s respawned at level %d in game room %s", ps.Player.Attr.Name, level, ps.Game.Name)
+	}
+	params["success"] = (err == nil)
+	ps.sendClientCommand(PlayerSessionCommand {
+		Method: "respawnResult",
+		Params: params,
+	})
+}
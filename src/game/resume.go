@@ -0,0 +1,171 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/gorilla/websocket"
+	"log"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+/**
+ * suspendedSession:
+ * The struct of a session which lost connection, its diep is frozen on the field until resumed or expired.
+ *
+ * @property {*PlayerSession} session													- the lost session
+ * @property {time.Time} expire																- the time when the diep is released
+ */
+type suspendedSession struct {
+	session *PlayerSession
+	expire time.Time
+}
+
+/**
+ * <game>.newResumeToken:
+ * The function to generate a random resume token.
+ *
+ * @return {string}
+ */
+func newResumeToken () string {
+	var buffer = make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		uuid, _ := util.NewUUID()
+		return uuid
+	}
+	return hex.EncodeToString(buffer)
+}
+
+/**
+ * <*PlayerSession>.start:
+ * The function in PlayerSession to run the routines after the session is added to the game,
+ * and send the resume token to client.
+ *
+ * @return {nil}
+ */
+func (ps *PlayerSession) start () {
+	// parallel execute receiver, ping and writer function, the snapshots come from the game loop
+	go ps.writer()
+	go ps.receiver()
+	go ps.ping()
+	if (ps.Game.Config.ResumeWindow > 0) {
+		ps.sendClientCommand(PlayerSessionCommand {
+			Method: "resumeToken",
+			Params: CommandParams {
+				"token": ps.ResumeToken,
+				"window": ps.Game.Config.ResumeWindow,
+			},
+		})
+	}
+}
+
+/**
+ * <*Game>.suspendSession:
+ * The function in Game to remove the lost session but keep its diep frozen and invulnerable for resuming.
+ * A diep without an active session is skipped in all collisions and by the traps.
+ *
+ * @param {*PlayerSession} session														- the lost session
+ *
+ * @return {bool}																							- false if resuming is disabled or the session is not in the game
+ */
+func (g *Game) suspendSession (session *PlayerSession) bool {
+	if (g.Config.ResumeWindow <= 0) {
+		return false
+	}
+	g.ControlLock.Lock()
+	defer g.ControlLock.Unlock()
+	for index, ps := range g.Sessions {
+		if (ps == session) {
+			g.Sessions = append(g.Sessions[:index], g.Sessions[index+1:]...)
+			g.suspended[session.ResumeToken] = &suspendedSession {
+				session: session,
+				expire: time.Now().Add(time.Duration(g.Config.ResumeWindow * float64(time.Second))),
+			}
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * <*Game>.expireSuspended:
+ * The function in Game to remove the dieps of the suspended sessions over the resume window.
+ * The caller should hold the game lock.
+ *
+ * @return {nil}
+ */
+func (g *Game) expireSuspended () {
+	if (len(g.suspended) == 0) {
+		return
+	}
+	var now = time.Now()
+	for token, suspended := range g.suspended {
+		if (now.Before(suspended.expire)) {
+			continue
+		}
+		delete(g.suspended, token)
+		g.removePlayer(suspended.session.Player)
+		g.Logger.closeConnection(suspended.session.Player.Attr.Name, g.Name, len(g.Sessions))
+		log.Printf("Player %s not resumed in time", suspended.session.Player.Attr.Name)
+		if (g.humanCount() == 0 && len(g.suspended) == 0) {
+			g.emptySince = now
+		}
+	}
+}
+
+/**
+ * <*Game>.SuspendedPlayer:
+ * The function in Game to find the player waiting for resuming by token.
+ *
+ * @param {string} token																			- the resume token
+ *
+ * @return {*Player}																					- nil if the token is unknown or expired
+ */
+func (g *Game) SuspendedPlayer (token string) *Player {
+	g.ControlLock.Lock()
+	defer g.ControlLock.Unlock()
+	suspended, ok := g.suspended[token]
+	if (!ok || time.Now().After(suspended.expire)) {
+		return nil
+	}
+	return suspended.session.Player
+}
+
+/**
+ * <*Game>.Resume:
+ * The function in Game to reattach the new connection to the suspended player by token.
+ *
+ * @param {string} token																			- the resume token
+ * @param {*websocket.Conn} ws																- the new websocket connection
+ * @param {Codec} codec																				- the codec negotiated by the new connection
+ *
+ * @return {*PlayerSession, error}
+ */
+func (g *Game) Resume (token string, ws *websocket.Conn, codec Codec) (*PlayerSession, error) {
+	g.ControlLock.Lock()
+	suspended, ok := g.suspended[token]
+	if (!ok || time.Now().After(suspended.expire)) {
+		g.ControlLock.Unlock()
+		return nil, errors.New("The resume token is invalid or expired!")
+	}
+	delete(g.suspended, token)
+	var player = suspended.session.Player
+	// the frozen diep starts again from rest
+	player.GameObject.Velocity = util.Vec2 {}
+	player.GameObject.Acceleration = util.Vec2 {}
+	var session = NewSession(ws, player, g)
+	session.ResumeToken = token
+	session.Codec = codec
+	// keep dropping the inputs sent before the connection lost
+	session.inputSeq = suspended.session.inputSeq
+	session.processedSeq = suspended.session.processedSeq
+	session.processedTime = suspended.session.processedTime
+	session.Dead = suspended.session.Dead
+	g.Sessions = append(g.Sessions, session)
+	g.emptySince = time.Time {}
+	g.ControlLock.Unlock()
+	session.start()
+	log.Printf("Player %s has resumed\n", player.Attr.Name)
+	return session, nil
+}
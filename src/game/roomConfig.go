@@ -0,0 +1,224 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the preset used when the room does not specify one
+const DefaultRoomPreset = "playground"
+
+/**
+ * RoomConfig:
+ * The struct of the settings of one game room.
+ *
+ * @property {util.Size} Field																- the size of the game field
+ * @property {float64} TickRate																- the number of simulation ticks per second
+ * @property {int} MaxMembers																	- the max number of the players in the room
+ * @property {int} StuffCap																		- the max number of the stuffs on the field
+ * @property {float64} StuffSpawnCurve												- the exponent of the stuff spawn delay curve
+ * @property {int} TrapCount																	- the max number of the traps on the field
+ * @property {float64} Friction																- the ratio of the velocity kept in every tick
+ * @property {string} Mode																		- the game mode of the room
+ * @property {float64} ResumeWindow														- the seconds to keep the diep of a lost player for resuming, 0 to disable
+ * @property {int} SnapshotInterval														- the number of ticks between two snapshots to players, 0 or 1 for every tick
+ * @property {float64} MaxRewind															- the max seconds to rewind the bullet hit test by the shooter latency, 0 to disable
+ * @property {int} BotCount																		- the number of players the bots fill the room up to, 0 to disable
+ * @property {bool} FriendlyFire															- whether the teammates can damage each other in the team modes
+ * @property {float64} BaseRadius															- the radius of the team bases at the field corners
+ * @property {float64} BaseDamage															- the HP per second the team bases take from the enemies inside
+ * @property {int} MinPlayers																	- the number of the players to start the battle royale lobby countdown
+ * @property {float64} LobbySeconds														- the seconds of the battle royale lobby countdown
+ * @property {[]ZonePhase} ZonePhases													- the phases of the battle royale safe zone
+ * @property {float64} RespawnKeep														- the ratio of the level kept after respawn in the modes allowing respawn
+ */
+type RoomConfig struct {
+	Field util.Size
+	TickRate float64
+	MaxMembers int
+	StuffCap int
+	StuffSpawnCurve float64
+	TrapCount int
+	Friction float64
+	Mode string
+	ResumeWindow float64
+	SnapshotInterval int
+	MaxRewind float64
+	BotCount int
+	FriendlyFire bool
+	BaseRadius float64
+	BaseDamage float64
+	MinPlayers int
+	LobbySeconds float64
+	ZonePhases []ZonePhase
+	RespawnKeep float64
+}
+
+// keep the room presets loaded once for all games
+var roomPresets map[string]RoomConfig
+var roomPresetsOnce sync.Once
+
+/**
+ * <game>.DefaultRoomConfig:
+ * The function to get the built-in room config, the same as the original playground.
+ *
+ * @return {RoomConfig}
+ */
+func DefaultRoomConfig () RoomConfig {
+	return RoomConfig {
+		Field: util.Size {
+			W: 8192,
+			H: 8192,
+		},
+		TickRate: 50,
+		MaxMembers: 100,
+		StuffCap: 1000,
+		StuffSpawnCurve: 1.618,
+		TrapCount: 20,
+		Friction: friction,
+		Mode: FFAMode,
+		ResumeWindow: 30,
+		SnapshotInterval: 1,
+		MaxRewind: 0.25,
+		BotCount: 0,
+		FriendlyFire: false,
+		BaseRadius: 600,
+		BaseDamage: 50,
+		MinPlayers: 2,
+		LobbySeconds: 30,
+		ZonePhases: []ZonePhase {},
+		RespawnKeep: 0.5,
+	}
+}
+
+/**
+ * <game>.GetRoomPresets:
+ * The function to get all named room presets, it reads the config file at the first call.
+ *
+ * @return {map[string]RoomConfig}
+ */
+func GetRoomPresets () map[string]RoomConfig {
+	roomPresetsOnce.Do(func () {
+		roomPresets = map[string]RoomConfig {}
+		jsonFile, err := os.Open("./src/config/room.json")
+		if (err == nil) {
+			defer jsonFile.Close()
+			byteValue, _ := ioutil.ReadAll(jsonFile)
+			err = json.Unmarshal(byteValue, &roomPresets)
+		}
+		if (err != nil) {
+			log.Print(err)
+		}
+		for name, config := range roomPresets {
+			if err := config.Validate(); err != nil {
+				log.Printf("[Error]: Invalid room preset %s: %s", name, err)
+				delete(roomPresets, name)
+			}
+		}
+		// always keep the default preset to create room with
+		if _, ok := roomPresets[DefaultRoomPreset]; !ok {
+			roomPresets[DefaultRoomPreset] = DefaultRoomConfig()
+		}
+	})
+	return roomPresets
+}
+
+/**
+ * <game>.GetRoomPreset:
+ * The function to get the room config by the preset name.
+ *
+ * @param {string} name																				- the name of the preset
+ *
+ * @return {RoomConfig, error}
+ */
+func GetRoomPreset (name string) (RoomConfig, error) {
+	if (name == "") {
+		name = DefaultRoomPreset
+	}
+	config, ok := GetRoomPresets()[name]
+	if (!ok) {
+		return RoomConfig {}, errors.New("Unknown room preset " + name + "!")
+	}
+	return config, nil
+}
+
+/**
+ * <RoomConfig>.Validate:
+ * The function in RoomConfig to check if all settings are in the valid range.
+ *
+ * @return {error}
+ */
+func (c RoomConfig) Validate () error {
+	if (c.Field.W <= 0) || (c.Field.H <= 0) {
+		return errors.New("The field size must be positive!")
+	}
+	if (c.TickRate <= 0) || (c.TickRate > 240) {
+		return errors.New("The tick rate must be in (0, 240]!")
+	}
+	if (c.MaxMembers <= 0) {
+		return errors.New("The max members must be positive!")
+	}
+	if (c.StuffCap < 0) || (c.TrapCount < 0) {
+		return errors.New("The stuff cap and trap count can not be negative!")
+	}
+	if (c.StuffSpawnCurve <= 0) {
+		return errors.New("The stuff spawn curve must be positive!")
+	}
+	if (c.Friction <= 0) || (c.Friction > 1) {
+		return errors.New("The friction must be in (0, 1]!")
+	}
+	if (c.ResumeWindow < 0) {
+		return errors.New("The resume window can not be negative!")
+	}
+	if (c.SnapshotInterval < 0) {
+		return errors.New("The snapshot interval can not be negative!")
+	}
+	if (c.MaxRewind < 0) || (c.MaxRewind > 1) {
+		return errors.New("The max rewind must be in [0, 1]!")
+	}
+	if (c.BotCount < 0) || (c.BotCount > c.MaxMembers) {
+		return errors.New("The bot count must be in [0, max members]!")
+	}
+	if (c.RespawnKeep < 0) || (c.RespawnKeep > 1) {
+		return errors.New("The respawn keep must be in [0, 1]!")
+	}
+	if _, err := NewGameMode(c); err != nil {
+		return err
+	}
+	if (c.Mode == Team2Mode) || (c.Mode == Team4Mode) {
+		if (c.BaseRadius <= 0) || (c.BaseRadius >= math.Min(c.Field.W, c.Field.H) / 2) {
+			return errors.New("The base radius must be in (0, half of the field size)!")
+		}
+		if (c.BaseDamage < 0) {
+			return errors.New("The base damage can not be negative!")
+		}
+	}
+	if (c.Mode == RoyaleMode) {
+		if (c.MinPlayers < 2) || (c.MinPlayers > c.MaxMembers) {
+			return errors.New("The min players must be in [2, max members]!")
+		}
+		if (c.LobbySeconds < 0) {
+			return errors.New("The lobby seconds can not be negative!")
+		}
+		if (len(c.ZonePhases) == 0) {
+			return errors.New("The battle royale needs at least one zone phase!")
+		}
+		var radius = math.Inf(1)
+		for _, phase := range c.ZonePhases {
+			if (phase.Wait < 0) || (phase.Shrink < 0) || (phase.Damage < 0) {
+				return errors.New("The zone phase times and damage can not be negative!")
+			}
+			if (phase.Radius < 0) || (phase.Radius > radius) {
+				return errors.New("The zone phase radius can not be negative or grow!")
+			}
+			radius = phase.Radius
+		}
+	}
+	return nil
+}
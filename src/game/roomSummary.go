@@ -0,0 +1,87 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+/**
+ * RoomSummary:
+ * The struct of the public information of one game room.
+ *
+ * @property {string} Name																		- the name of the game room
+ * @property {int} Members																		- the number of the players in the room
+ * @property {int} Bots																				- the number of the bots in the room
+ * @property {int} Capacity																		- the max number of the players in the room
+ * @property {string} Mode																		- the game mode of the room
+ * @property {float64} Uptime																	- the seconds since the room is opened
+ */
+type RoomSummary struct {
+	Name string
+	Members int
+	Bots int
+	Capacity int
+	Mode string
+	Uptime float64
+}
+
+/**
+ * LeaderboardEntry:
+ * The struct of one player on the leaderboard.
+ *
+ * @property {string} Name																		- the name of the player
+ * @property {int} Score																			- the score of the player
+ * @property {int} Level																			- the level of the player
+ * @property {string} Class																		- the tank class of the player
+ */
+type LeaderboardEntry struct {
+	Name string
+	Score int
+	Level int
+	Class string
+}
+
+/**
+ * <*Game>.Summary:
+ * The function in Game to get the public information of the room.
+ *
+ * @return {RoomSummary}
+ */
+func (g *Game) Summary () RoomSummary {
+	g.ControlLock.Lock()
+	var members = g.humanCount()
+	var bots = len(g.Sessions) - members
+	g.ControlLock.Unlock()
+	return RoomSummary {
+		Name: g.Name,
+		Members: members,
+		Bots: bots,
+		Capacity: g.Config.MaxMembers,
+		Mode: g.Config.Mode,
+		Uptime: time.Since(g.CreatedAt).Seconds(),
+	}
+}
+
+/**
+ * <*Game>.Leaderboard:
+ * The function in Game to get all players in the room ordered by score.
+ *
+ * @return {[]LeaderboardEntry}
+ */
+func (g *Game) Leaderboard () []LeaderboardEntry {
+	g.ControlLock.Lock()
+	var entries = make([]LeaderboardEntry, 0, len(g.Sessions))
+	for _, ps := range g.Sessions {
+		entries = append(entries, LeaderboardEntry {
+			Name: ps.Player.Attr.Name,
+			Score: ps.Player.Attr.Score,
+			Level: ps.Player.Attr.Level,
+			Class: ps.Player.Attr.Class,
+		})
+	}
+	g.ControlLock.Unlock()
+	sort.SliceStable(entries, func (i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	return entries
+}
@@ -0,0 +1,421 @@
+package game
+
+import (
+	"log"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the kind of the items in the view
+const (
+	entityDiep uint8 = iota + 1
+	entityStuff
+	entityTrap
+	entityBullet
+)
+
+// define the bits of the changed fields in the entity update
+const (
+	updatePosition uint8 = 1 << iota
+	updateRotation
+	updateRadius
+	updateHP
+	updateClass
+)
+
+/**
+ * EntitySnapshot:
+ * The struct of one item in the player view sent to client.
+ *
+ * @property {uint32} NetId																		- the network id of the item
+ * @property {float64} X																			- the x of the position
+ * @property {float64} Y																			- the y of the position
+ * @property {float64} Rotation																- the rotation in radian
+ * @property {float64} Radius																	- the collision circle radius
+ * @property {int} Type																				- the type number of the stuff or the trap, 0 for others
+ */
+type EntitySnapshot struct {
+	NetId uint32
+	X float64
+	Y float64
+	Rotation float64
+	Radius float64
+	Type int
+}
+
+/**
+ * DiepSnapshot:
+ * The struct of one diep in the player view sent to client.
+ *
+ * @property {EntitySnapshot} 																- the common item information
+ * @property {string} Name																		- the name of the player
+ * @property {string} Class																		- the tank class of the player
+ * @property {float64} HP																			- the HP of the player
+ * @property {int} Team																				- the team of the player, 0 if the game mode has no team
+ */
+type DiepSnapshot struct {
+	EntitySnapshot
+	Name string
+	Class string
+	HP float64
+	Team int
+}
+
+/**
+ * EntityUpdate:
+ * The struct of the changed fields of one item since the baseline, only the fields in the mask are meaningful.
+ *
+ * @property {uint32} NetId																		- the network id of the item
+ * @property {uint8} Mask																			- the bits of the changed fields
+ * @property {float64} X																			- the x of the position
+ * @property {float64} Y																			- the y of the position
+ * @property {float64} Rotation																- the rotation in radian
+ * @property {float64} Radius																	- the collision circle radius
+ * @property {float64} HP																			- the HP of the diep
+ * @property {string} Class																		- the tank class of the diep
+ */
+type EntityUpdate struct {
+	NetId uint32
+	Mask uint8
+	X float64
+	Y float64
+	Rotation float64
+	Radius float64
+	HP float64
+	Class string
+}
+
+/**
+ * viewEntity:
+ * The struct of one item kept in the player view.
+ *
+ * @property {uint8} Kind																			- the kind of the item
+ * @property {DiepSnapshot} 																	- the state of the item, the diep fields are empty for others
+ */
+type viewEntity struct {
+	Kind uint8
+	DiepSnapshot
+}
+
+/**
+ * PlayerSnapshot:
+ * The struct of the own player information sent to client.
+ *
+ * @property {DiepSnapshot} 																	- the diep of the player
+ * @property {int} Level																			- the level of the player
+ * @property {int} EXP																				- the EXP of the player
+ * @property {int} Score																			- the score of the player
+ * @property {int} SkillPoint																	- the unspent skill points of the player
+ * @property {PlayerStatus} Status														- the stat levels of the player
+ */
+type PlayerSnapshot struct {
+	DiepSnapshot
+	Level int
+	EXP int
+	Score int
+	SkillPoint int
+	Status PlayerStatus
+}
+
+/**
+ * ZoneSnapshot:
+ * The struct of the safe zone of the game mode sent to client.
+ *
+ * @property {bool} Active																		- whether the game mode has the safe zone
+ * @property {int} Phase																			- the current phase from 1, 0 before the round starts
+ * @property {float64} X																			- the x of the zone center
+ * @property {float64} Y																			- the y of the zone center
+ * @property {float64} Radius																	- the radius of the zone
+ * @property {float64} NextX																	- the x of the zone center after the phase
+ * @property {float64} NextY																	- the y of the zone center after the phase
+ * @property {float64} NextRadius															- the radius of the zone after the phase
+ * @property {float64} Countdown															- the seconds before the round starts or the zone shrinks, 0 if not counting
+ */
+type ZoneSnapshot struct {
+	Active bool
+	Phase int
+	X float64
+	Y float64
+	Radius float64
+	NextX float64
+	NextY float64
+	NextRadius float64
+	Countdown float64
+}
+
+/**
+ * Snapshot:
+ * The struct of the state sent to one player every frame, every codec encodes this one definition.
+ * A full snapshot has BaseTick 0 and lists all items in view. A delta snapshot lists the items
+ * created since the baseline view at BaseTick, the updated fields and the destroyed items.
+ *
+ * @property {uint64} Tick																		- the simulation tick of the state
+ * @property {uint64} BaseTick																- the tick of the baseline view, 0 for a full snapshot
+ * @property {uint32} InputSeq																- the sequence number of the last input of the player applied
+ * @property {float64} InputTime															- the client timestamp of the last input of the player applied
+ * @property {[]int} TeamScores																- the kills of every team from the team 1, empty if the game mode has no team
+ * @property {ZoneSnapshot} Zone															- the safe zone of the game mode
+ * @property {util.Size} Field																- the size of the game field
+ * @property {PlayerSnapshot} Player													- the own player
+ * @property {[]DiepSnapshot} Dieps														- the dieps created in view
+ * @property {[]EntitySnapshot} Stuffs												- the stuffs created in view
+ * @property {[]EntitySnapshot} Traps													- the traps created in view
+ * @property {[]EntitySnapshot} Bullets												- the bullets created in view
+ * @property {[]EntityUpdate} Updates													- the changed items since the baseline
+ * @property {[]uint32} Destroyed															- the network ids of the items gone since the baseline
+ */
+type Snapshot struct {
+	Tick uint64
+	BaseTick uint64
+	InputSeq uint32
+	InputTime float64
+	TeamScores []int
+	Zone ZoneSnapshot
+	Field util.Size
+	Player PlayerSnapshot
+	Dieps []DiepSnapshot
+	Stuffs []EntitySnapshot
+	Traps []EntitySnapshot
+	Bullets []EntitySnapshot
+	Updates []EntityUpdate
+	Destroyed []uint32
+}
+
+/**
+ * <game>.newEntitySnapshot:
+ * The function to get the snapshot of one item.
+ *
+ * @param {GameObjectInterface} object												- the target item
+ *
+ * @return {EntitySnapshot}
+ */
+func newEntitySnapshot (object GameObjectInterface) EntitySnapshot {
+	var game_object = object.GetGameObject()
+	var entity = EntitySnapshot {
+		NetId: game_object.NetId,
+		X: game_object.Position.X,
+		Y: game_object.Position.Y,
+		Rotation: game_object.Rotation,
+		Radius: game_object.Radius,
+	}
+	switch target := object.(type) {
+		case *Stuff:
+			entity.Type = target.Type
+		case *Trap:
+			entity.Type = target.Type
+	}
+	return entity
+}
+
+/**
+ * <game>.newDiepSnapshot:
+ * The function to get the snapshot of one player diep.
+ *
+ * @param {*Player} player																		- the player of the diep
+ *
+ * @return {DiepSnapshot}
+ */
+func newDiepSnapshot (player *Player) DiepSnapshot {
+	return DiepSnapshot {
+		EntitySnapshot: newEntitySnapshot(player),
+		Name: player.Attr.Name,
+		Class: player.Attr.Class,
+		HP: player.Attr.HP,
+		Team: player.Team,
+	}
+}
+
+/**
+ * <game>.newViewEntity:
+ * The function to get the state of one item to keep in the view.
+ *
+ * @param {uint8} kind																				- the kind of the item
+ * @param {GameObjectInterface} object												- the target item
+ *
+ * @return {viewEntity}
+ */
+func newViewEntity (kind uint8, object GameObjectInterface) viewEntity {
+	if diep, ok := object.(*Diep); ok {
+		return viewEntity {
+			Kind: kind,
+			DiepSnapshot: newDiepSnapshot(diep.Player),
+		}
+	}
+	return viewEntity {
+		Kind: kind,
+		DiepSnapshot: DiepSnapshot {
+			EntitySnapshot: newEntitySnapshot(object),
+		},
+	}
+}
+
+/**
+ * <viewEntity>.diff:
+ * The function in viewEntity to get the update of the changed fields from the baseline.
+ *
+ * @param {viewEntity} base																		- the state in the baseline view
+ *
+ * @return {EntityUpdate}																			- the mask is 0 if nothing changed
+ */
+func (e viewEntity) diff (base viewEntity) EntityUpdate {
+	var update = EntityUpdate {
+		NetId: e.NetId,
+	}
+	if (e.X != base.X) || (e.Y != base.Y) {
+		update.Mask |= updatePosition
+		update.X = e.X
+		update.Y = e.Y
+	}
+	if (e.Rotation != base.Rotation) {
+		update.Mask |= updateRotation
+		update.Rotation = e.Rotation
+	}
+	if (e.Radius != base.Radius) {
+		update.Mask |= updateRadius
+		update.Radius = e.Radius
+	}
+	if (e.HP != base.HP) {
+		update.Mask |= updateHP
+		update.HP = e.HP
+	}
+	if (e.Class != base.Class) {
+		update.Mask |= updateClass
+		update.Class = e.Class
+	}
+	return update
+}
+
+/**
+ * <*Snapshot>.addCreated:
+ * The function in Snapshot to add the item to the list of its kind.
+ *
+ * @param {viewEntity} entity																	- the created item
+ *
+ * @return {nil}
+ */
+func (s *Snapshot) addCreated (entity viewEntity) {
+	switch (entity.Kind) {
+		case entityDiep:
+			s.Dieps = append(s.Dieps, entity.DiepSnapshot)
+		case entityStuff:
+			s.Stuffs = append(s.Stuffs, entity.EntitySnapshot)
+		case entityTrap:
+			s.Traps = append(s.Traps, entity.EntitySnapshot)
+		case entityBullet:
+			s.Bullets = append(s.Bullets, entity.EntitySnapshot)
+	}
+}
+
+/**
+ * <*PlayerSession>.buildSnapshot:
+ * The function in PlayerSession to get the snapshot of the current view.
+ * The snapshot is a delta against the last acknowledged view, or a full one if
+ * the client has not acknowledged any view or the view is out of the history.
+ * The caller should hold the game lock and the session lock after updating the view.
+ *
+ * @return {*Snapshot}
+ */
+func (ps *PlayerSession) buildSnapshot () *Snapshot {
+	var current = &ps.views[ps.viewIndex]
+	var baseline = ps.findView(ps.ackTick)
+	var snapshot = &Snapshot {
+		Tick: current.Tick,
+		InputSeq: ps.processedSeq,
+		InputTime: ps.processedTime,
+		TeamScores: ps.Game.Mode.TeamScores(),
+		Zone: ps.Game.Mode.Zone(ps.Game),
+		Field: *ps.Game.Field,
+		Player: PlayerSnapshot {
+			DiepSnapshot: newDiepSnapshot(ps.Player),
+			Level: ps.Player.Attr.Level,
+			EXP: ps.Player.Attr.EXP,
+			Score: ps.Player.Attr.Score,
+			SkillPoint: ps.Player.Attr.SkillPoint,
+			Status: ps.Player.Status,
+		},
+		Dieps: []DiepSnapshot {},
+		Stuffs: []EntitySnapshot {},
+		Traps: []EntitySnapshot {},
+		Bullets: []EntitySnapshot {},
+		Updates: []EntityUpdate {},
+		Destroyed: []uint32 {},
+	}
+	if (baseline == nil) {
+		for _, entity := range current.Entities {
+			snapshot.addCreated(entity)
+		}
+		return snapshot
+	}
+	snapshot.BaseTick = baseline.Tick
+	for id, entity := range current.Entities {
+		base, ok := baseline.Entities[id]
+		if (!ok) {
+			snapshot.addCreated(entity)
+		} else if update := entity.diff(base); update.Mask != 0 {
+			snapshot.Updates = append(snapshot.Updates, update)
+		}
+	}
+	for id := range baseline.Entities {
+		if _, ok := current.Entities[id]; !ok {
+			snapshot.Destroyed = append(snapshot.Destroyed, id)
+		}
+	}
+	return snapshot
+}
+
+/**
+ * sessionSnapshot:
+ * The struct of the snapshot built for one session at the end of a tick.
+ * The snapshot shares no data with the game, so it is never changed after built.
+ *
+ * @property {*PlayerSession} session													- the target session
+ * @property {*Snapshot} snapshot															- the snapshot for the session
+ */
+type sessionSnapshot struct {
+	session *PlayerSession
+	snapshot *Snapshot
+}
+
+/**
+ * <*Game>.buildSnapshots:
+ * The function in Game to update the views and build the snapshots for all sessions.
+ * The caller should hold the game lock with the spatial index rebuilt.
+ *
+ * @return {[]sessionSnapshot}
+ */
+func (g *Game) buildSnapshots () []sessionSnapshot {
+	var snapshots = make([]sessionSnapshot, 0, len(g.Sessions))
+	for _, ps := range g.Sessions {
+		// the bots read the game directly
+		if (ps.IsBot()) {
+			continue
+		}
+		ps.ControlLock.Lock()
+		ps.updateView()
+		snapshots = append(snapshots, sessionSnapshot {
+			session: ps,
+			snapshot: ps.buildSnapshot(),
+		})
+		ps.ControlLock.Unlock()
+	}
+	return snapshots
+}
+
+/**
+ * <*Game>.sendSnapshots:
+ * The function in Game to encode the snapshots in the codec of each session and hand them to the writers.
+ * It should be called without the game lock.
+ *
+ * @param {[]sessionSnapshot} snapshots												- the snapshots to send
+ *
+ * @return {nil}
+ */
+func (g *Game) sendSnapshots (snapshots []sessionSnapshot) {
+	for _, item := range snapshots {
+		message_b, err := item.session.Codec.EncodeSnapshot(item.snapshot)
+		if (err != nil) {
+			log.Println("[Error]: Encode snapshot:", err)
+			continue
+		}
+		item.session.queueSnapshot(message_b)
+	}
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"math"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the default cell size of the spatial hash
+const spatialCellSize = 256.0
+
+/**
+ * SpatialHash:
+ * The struct of uniform grid to look up the game objects near a position.
+ * Every object is bucketed by its center, and queries are expanded by the largest
+ * radius in the grid, so an object overlapping a cell border is still found.
+ *
+ * @property {float64} CellSize																- the width and height of one cell
+ * @property {int} columns																		- the number of the columns in the grid
+ * @property {int} rows																				- the number of the rows in the grid
+ * @property {[][]GameObjectInterface} cells									- the objects bucketed by cell
+ * @property {float64} maxRadius															- the largest object radius in the grid
+ */
+type SpatialHash struct {
+	CellSize float64
+	columns int
+	rows int
+	cells [][]GameObjectInterface
+	maxRadius float64
+}
+
+/**
+ * MapIndex:
+ * The struct to keep one spatial hash for each kind of item on the field.
+ *
+ * @property {*SpatialHash} Dieps					 										- the spatial hash of Dieps on the field
+ * @property {*SpatialHash} Bullets					 									- the spatial hash of Bullets on the field
+ * @property {*SpatialHash} Stuffs					 									- the spatial hash of Stuffs on the field
+ * @property {*SpatialHash} Traps					 										- the spatial hash of Traps on the field
+ */
+type MapIndex struct {
+	Dieps *SpatialHash
+	Bullets *SpatialHash
+	Stuffs *SpatialHash
+	Traps *SpatialHash
+}
+
+/**
+ * <game>.NewSpatialHash:
+ * The function to new an empty spatial hash covering the field.
+ *
+ * @param {float64} cellSize																	- the width and height of one cell
+ * @param {*util.Size} field																	- the size of the game field
+ *
+ * @return {*SpatialHash}
+ */
+func NewSpatialHash (cellSize float64, field *util.Size) *SpatialHash {
+	var columns = int(math.Ceil(field.W / cellSize)) + 1
+	var rows = int(math.Ceil(field.H / cellSize)) + 1
+	return &SpatialHash {
+		CellSize: cellSize,
+		columns: columns,
+		rows: rows,
+		cells: make([][]GameObjectInterface, columns * rows),
+		maxRadius: 0,
+	}
+}
+
+/**
+ * <game>.NewMapIndex:
+ * The function to new an empty map index.
+ *
+ * @param {*util.Size} field																	- the size of the game field
+ *
+ * @return {MapIndex}
+ */
+func NewMapIndex (field *util.Size) MapIndex {
+	return MapIndex {
+		Dieps: NewSpatialHash(spatialCellSize, field),
+		Bullets: NewSpatialHash(spatialCellSize, field),
+		Stuffs: NewSpatialHash(spatialCellSize, field),
+		Traps: NewSpatialHash(spatialCellSize, field),
+	}
+}
+
+/**
+ * <*SpatialHash>.cellOf:
+ * The function in SpatialHash to get the cell coordinate of a position clamped into the grid.
+ *
+ * @param {float64} x																					- the x of the position
+ * @param {float64} y																					- the y of the position
+ *
+ * @return {int, int}
+ */
+func (h *SpatialHash) cellOf (x, y float64) (int, int) {
+	var cx = int(math.Max(math.Min(math.Floor(x / h.CellSize), float64(h.columns - 1)), 0))
+	var cy = int(math.Max(math.Min(math.Floor(y / h.CellSize), float64(h.rows - 1)), 0))
+	return cx, cy
+}
+
+/**
+ * <*SpatialHash>.Clear:
+ * The function in SpatialHash to remove all objects but keep the allocated buckets.
+ *
+ * @return {nil}
+ */
+func (h *SpatialHash) Clear () {
+	for i := range h.cells {
+		h.cells[i] = h.cells[i][:0]
+	}
+	h.maxRadius = 0
+}
+
+/**
+ * <*SpatialHash>.Insert:
+ * The function in SpatialHash to add an object into the cell of its center.
+ *
+ * @param {GameObjectInterface} object												- the target object
+ *
+ * @return {nil}
+ */
+func (h *SpatialHash) Insert (object GameObjectInterface) {
+	var game_object = object.GetGameObject()
+	cx, cy := h.cellOf(game_object.Position.X, game_object.Position.Y)
+	h.cells[cy * h.columns + cx] = append(h.cells[cy * h.columns + cx], object)
+	h.maxRadius = math.Max(h.maxRadius, game_object.Radius)
+}
+
+/**
+ * <*SpatialHash>.QueryRect:
+ * The function in SpatialHash to get all objects which may overlap the rectangle.
+ *
+ * @param {util.Vec2} min																			- the top-left corner of the rectangle
+ * @param {util.Vec2} max																			- the bottom-right corner of the rectangle
+ *
+ * @return {[]GameObjectInterface}
+ */
+func (h *SpatialHash) QueryRect (min, max util.Vec2) []GameObjectInterface {
+	var result = []GameObjectInterface {}
+	// expand the range to catch the objects centered in the neighbour cells
+	cx_min, cy_min := h.cellOf(min.X - h.maxRadius, min.Y - h.maxRadius)
+	cx_max, cy_max := h.cellOf(max.X + h.maxRadius, max.Y + h.maxRadius)
+	for cx := cx_min; cx <= cx_max; cx++ {
+		for cy := cy_min; cy <= cy_max; cy++ {
+			result = append(result, h.cells[cy * h.columns + cx]...)
+		}
+	}
+	return result
+}
+
+/**
+ * <*SpatialHash>.QueryCircle:
+ * The function in SpatialHash to get all objects which may overlap the circle.
+ *
+ * @param {util.Vec2} center																	- the center of the circle
+ * @param {float64} radius																		- the radius of the circle
+ *
+ * @return {[]GameObjectInterface}
+ */
+func (h *SpatialHash) QueryCircle (center util.Vec2, radius float64) []GameObjectInterface {
+	var extent = util.Vec2 { X: radius, Y: radius }
+	return h.QueryRect(center.Sub(extent), center.Add(extent))
+}
+
+/**
+ * <*Game>.rebuildIndex:
+ * The function in Game to re-bucket all items on the field after the movement of this tick.
+ * The items new on the field get their network id here, so every item in the index has one.
+ *
+ * @return {nil}
+ */
+func (g *Game) rebuildIndex () {
+	g.MapInfo.Index.Dieps.Clear()
+	for _, diep := range g.MapInfo.Dieps {
+		g.assignNetId(diep.GameObject)
+		g.MapInfo.Index.Dieps.Insert(diep)
+	}
+	g.MapInfo.Index.Bullets.Clear()
+	for _, bullet := range g.MapInfo.Bullets {
+		g.assignNetId(&bullet.GameObject)
+		g.MapInfo.Index.Bullets.Insert(bullet)
+	}
+	g.MapInfo.Index.Stuffs.Clear()
+	for _, stuff := range g.MapInfo.Stuffs {
+		g.assignNetId(&stuff.GameObject)
+		g.MapInfo.Index.Stuffs.Insert(stuff)
+	}
+	g.MapInfo.Index.Traps.Clear()
+	for _, trap := range g.MapInfo.Traps {
+		g.assignNetId(&trap.GameObject)
+		g.MapInfo.Index.Traps.Insert(trap)
+	}
+}
+
+/**
+ * <*Game>.assignNetId:
+ * The function in Game to give the object a network id unique in the game if it has none.
+ *
+ * @param {*GameObject} object																- the target object
+ *
+ * @return {nil}
+ */
+func (g *Game) assignNetId (object *GameObject) {
+	if (object.NetId == 0) {
+		g.nextNetId++
+		object.NetId = g.nextNetId
+	}
+}
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the class of the new player
+const defaultTankClass = "Basic"
+// define the reload time of the barrel with reload multiplier 1 in second
+const baseReloadSeconds = 0.6
+// define the radius of the bullet with size multiplier 1
+const baseBulletRadius = 20.0
+
+/**
+ * Barrel:
+ * The struct of one barrel on the tank.
+ *
+ * @property {float64} Offset																	- the lateral offset from the tank center
+ * @property {float64} Angle																	- the angle relative to the aim direction in degree
+ * @property {float64} Spread																	- the max random deviation of the bullet in degree
+ * @property {float64} Reload																	- the multiplier of the reload time
+ * @property {float64} BulletSize															- the multiplier of the bullet radius
+ */
+type Barrel struct {
+	Offset float64
+	Angle float64
+	Spread float64
+	Reload float64
+	BulletSize float64
+}
+
+/**
+ * TankClass:
+ * The struct of the tank class definition loaded from the config file.
+ *
+ * @property {int} Level																			- the level required to upgrade into the class
+ * @property {[]string} UpgradeFrom														- the classes which can upgrade into the class
+ * @property {[]Barrel} Barrels																- the barrels of the class
+ */
+type TankClass struct {
+	Level int
+	UpgradeFrom []string
+	Barrels []Barrel
+}
+
+// keep the tank classes loaded once for all games
+var tankClasses map[string]TankClass
+var tankClassesOnce sync.Once
+
+/**
+ * <game>.GetTankClasses:
+ * The function to get all tank classes, it reads the config file at the first call.
+ *
+ * @return {map[string]TankClass}
+ */
+func GetTankClasses () map[string]TankClass {
+	tankClassesOnce.Do(func () {
+		tankClasses = map[string]TankClass {}
+		jsonFile, err := os.Open("./src/config/tankClass.json")
+		if (err == nil) {
+			defer jsonFile.Close()
+			byteValue, _ := ioutil.ReadAll(jsonFile)
+			err = json.Unmarshal(byteValue, &tankClasses)
+		}
+		if (err != nil) {
+			log.Print(err)
+		}
+		// always keep the default class to shoot with
+		if _, ok := tankClasses[defaultTankClass]; !ok {
+			tankClasses[defaultTankClass] = TankClass {
+				Level: 1,
+				UpgradeFrom: []string {},
+				Barrels: []Barrel {
+					{ Offset: 0, Angle: 0, Spread: 0, Reload: 1, BulletSize: 1 },
+				},
+			}
+		}
+	})
+	return tankClasses
+}
+
+/**
+ * <*Player>.tankClass:
+ * The function in Player to get the definition of the current class.
+ *
+ * @return {TankClass}
+ */
+func (p *Player) tankClass () TankClass {
+	var classes = GetTankClasses()
+	if class, ok := classes[p.Attr.Class]; ok {
+		return class
+	}
+	return classes[defaultTankClass]
+}
+
+/**
+ * <*Player>.AvailableClasses:
+ * The function in Player to get the classes the player can upgrade into now.
+ *
+ * @return {[]string}
+ */
+func (p *Player) AvailableClasses () []string {
+	var result = []string {}
+	for name, class := range GetTankClasses() {
+		if (class.Level > p.Attr.Level) {
+			continue
+		}
+		for _, from := range class.UpgradeFrom {
+			if (from == p.Attr.Class) {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+/**
+ * <*Player>.UpgradeClass:
+ * The function in Player to upgrade into the target class.
+ *
+ * @param {string} name																				- the name of the target class
+ *
+ * @return {error}
+ */
+func (p *Player) UpgradeClass (name string) error {
+	for _, available := range p.AvailableClasses() {
+		if (available == name) {
+			p.Attr.Class = name
+			// reset the reload of the new barrels
+			p.reloads = nil
+			return nil
+		}
+	}
+	if _, ok := GetTankClasses()[name]; !ok {
+		return errors.New("Unknown class " + name + "!")
+	}
+	return errors.New("You can not upgrade to " + name + " now!")
+}
+
+/**
+ * <*Player>.reloadBarrels:
+ * The function in Player to count down the reload time of every barrel in one tick.
+ *
+ * @return {nil}
+ */
+func (p *Player) reloadBarrels () {
+	var shoot_cd = 0.0
+	for i := range p.reloads {
+		p.reloads[i] = math.Max(p.reloads[i] - 1, 0)
+		if (i == 0) || (p.reloads[i] < shoot_cd) {
+			shoot_cd = p.reloads[i]
+		}
+	}
+	// keep the shortest reload time for the client
+	p.Attr.ShootCD = shoot_cd
+}
+
+/**
+ * <*Player>.fireBarrels:
+ * The function in Player to spawn one bullet from every reloaded barrel of the current class.
+ *
+ * @param {float64} angle																			- the aim direction in degree
+ * @param {float64} framerate																	- the framerate of the game to convert the reload time to ticks
+ *
+ * @return {[]*Bullet}
+ */
+func (p *Player) fireBarrels (angle, framerate float64) []*Bullet {
+	var class = p.tankClass()
+	if (len(p.reloads) != len(class.Barrels)) {
+		p.reloads = make([]float64, len(class.Barrels))
+	}
+	var bullets = []*Bullet {}
+	var speed = float64(p.Status.BulletSpeed + 10) / ratio
+	for i, barrel := range class.Barrels {
+		if (p.reloads[i] > 0) {
+			continue
+		}
+		// place the bullet at the muzzle of the barrel
+		var barrel_angle = (angle + barrel.Angle) / 360 * 2 * math.Pi
+		var forward = util.Vec2 { X: 1 }.Rotate(barrel_angle)
+		var side = forward.Rotate(math.Pi / 2)
+		// deviate the bullet randomly inside the spread
+		var direction = barrel_angle + (rand.Float64() * 2 - 1) * barrel.Spread / 360 * 2 * math.Pi
+		uuid, _ := util.NewUUID()
+		bullets = append(bullets, &Bullet {
+			GameObject: GameObject {
+				Id: uuid,
+				Position: p.Position.Add(side.Scale(barrel.Offset)).Add(forward.Scale(p.Radius)),
+				Mass: 0.1 * barrel.BulletSize,
+				Radius: baseBulletRadius * barrel.BulletSize,
+				Velocity: util.Vec2 { X: 1 }.Rotate(direction).Scale(speed),
+				Rotation: direction,
+			},
+			Damage: p.Status.BulletDamage,
+			Existence: (p.Status.BulletPenetration - 1) * 40 +  250,
+			Owner: p.Id,
+			Team: p.Team,
+		})
+		p.reloads[i] = framerate * baseReloadSeconds * barrel.Reload / (1 + float64(p.Status.BulletReload - 1) * 0.15)
+	}
+	return bullets
+}
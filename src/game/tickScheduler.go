@@ -0,0 +1,63 @@
+package game
+
+import (
+	"time"
+)
+
+/**
+ * TickScheduler:
+ * The struct to drive the fixed-timestep simulation clock of the game.
+ *
+ * @property {time.Duration} Step															- the fixed duration of one tick
+ * @property {int} MaxCatchUp																	- the max number of overdue ticks to run back-to-back
+ * @property {uint64} Tick																		- the number of the last simulated tick
+ * @property {time.Time} next																	- the scheduled time of the next tick
+ */
+type TickScheduler struct {
+	Step time.Duration
+	MaxCatchUp int
+	Tick uint64
+	next time.Time
+}
+
+/**
+ * <game>.NewTickScheduler:
+ * The function to new a tick scheduler.
+ *
+ * @param {float64} framerate																	- the number of ticks per second
+ * @param {int} maxCatchUp																		- the max number of overdue ticks to run back-to-back
+ *
+ * @return {*TickScheduler}
+ */
+func NewTickScheduler (framerate float64, maxCatchUp int) *TickScheduler {
+	return &TickScheduler {
+		Step: time.Duration(float64(time.Second) / framerate),
+		MaxCatchUp: maxCatchUp,
+		Tick: 0,
+		next: time.Now(),
+	}
+}
+
+/**
+ * <*TickScheduler>.Wait:
+ * The function in TickScheduler to block until the next tick is due and advance the tick number.
+ * If the loop is late by no more than MaxCatchUp ticks, the overdue ticks run back-to-back
+ * without sleeping. If it is later than that, the overdue ticks are skipped so the
+ * simulation does not spiral behind the wall clock.
+ *
+ * @return {int}																							- the number of the skipped ticks
+ */
+func (s *TickScheduler) Wait () int {
+	var skipped = 0
+	now := time.Now()
+	if (now.Before(s.next)) {
+		time.Sleep(s.next.Sub(now))
+	} else if behind := now.Sub(s.next); behind > time.Duration(s.MaxCatchUp) * s.Step {
+		// drop the overdue ticks over the catch-up limit
+		skipped = int(behind / s.Step)
+		s.next = s.next.Add(time.Duration(skipped) * s.Step)
+	}
+	s.Tick++
+	s.next = s.next.Add(s.Step)
+	return skipped
+}
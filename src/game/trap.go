@@ -0,0 +1,116 @@
+package game
+
+import (
+	"math"
+	"time"
+	"github.com/f26401004/Lifegamer-Diep-backend/src/util"
+)
+
+// define the delay between two trap placements
+const trapSpawnDelay = 5 * time.Second
+
+/**
+ * <*Game>.updateTraps:
+ * The function in Game to let every trap aim at the nearest diep in range and shoot when reloaded.
+ *
+ * @return {nil}
+ */
+func (g *Game) updateTraps () {
+	for _, trap := range g.MapInfo.Traps {
+		trap.reload = math.Max(trap.reload - 1, 0)
+		// search the nearest diep in range
+		var target *Diep = nil
+		var distance = trap.Attr.Range
+		for _, object := range g.MapInfo.Index.Dieps.QueryCircle(trap.Position, trap.Attr.Range) {
+			diep := object.(*Diep)
+			// skip the diep frozen for resuming
+			if (g.findSession(diep.GameObject.Id) == nil) {
+				continue
+			}
+			if d := diep.Position.Sub(trap.Position).Length(); d <= distance {
+				target = diep
+				distance = d
+			}
+		}
+		if (target == nil) {
+			continue
+		}
+		// turn to the target
+		var forward = target.Position.Sub(trap.Position).Normalize()
+		trap.Rotation = math.Atan2(forward.Y, forward.X)
+		if (trap.reload > 0) {
+			continue
+		}
+		uuid, _ := util.NewUUID()
+		g.MapInfo.Bullets = append(g.MapInfo.Bullets, &Bullet {
+			GameObject: GameObject {
+				Id: uuid,
+				Position: trap.Position.Add(forward.Scale(trap.Radius)),
+				Mass: 0.1,
+				Radius: baseBulletRadius,
+				Velocity: forward.Scale(float64(trap.Attr.BulletSpeed + 10) / ratio),
+				Rotation: trap.Rotation,
+			},
+			Damage: trap.Attr.BulletDamage,
+			Existence: 250,
+			Owner: trap.Id,
+		})
+		trap.reload = float64(trap.Attr.BulletReload)
+	}
+}
+
+/**
+ * <*Game>.detectTrapBulletCollision:
+ * The function in Game to detect if there is collision between trap and player bullet.
+ *
+ * @return {nil}
+ */
+func (g *Game) detectTrapBulletCollision () {
+	for _, trap := range g.MapInfo.Traps {
+		for _, object := range g.MapInfo.Index.Bullets.QueryCircle(trap.Position, trap.Radius) {
+			bullet := object.(*Bullet)
+			// only the bullet of the player can damage the trap
+			if (g.findSession(bullet.Owner) == nil) {
+				continue
+			}
+			if (trap.GameObject.Overlap(&bullet.GameObject)) {
+				g.MapInfo.Collisions = append(g.MapInfo.Collisions, CollisionDetection {
+					object_a: trap,
+					object_b: bullet,
+				})
+			}
+		}
+	}
+}
+
+/**
+ * <*Game>.dealWithTrapCollision:
+ * The function in Game to apply the collision effect between trap and player bullet.
+ *
+ * @param {*Trap} trap																				- the trap in the collision
+ * @param {GameObjectInterface} target												- the collider
+ *
+ * @return {nil}
+ */
+func (g *Game) dealWithTrapCollision (trap *Trap, target GameObjectInterface) {
+	bullet, ok := target.(*Bullet)
+	// skip the trap or the bullet already destroyed in this tick
+	if (!ok) || (trap.Attr.HP <= 0) || (bullet.Existence <= 0) {
+		return
+	}
+	trap.Attr.HP -= float64(bullet.Damage) * 5.0
+	// remove the bullet
+	bullet.Existence = 0
+	g.removeBullet(bullet)
+	if (trap.Attr.HP > 0) {
+		return
+	}
+	// log the dead message
+	g.Logger.deadMessage(trap.GameObject.Id, bullet.Owner)
+	// award the exp to the owner of the bullet
+	if ps := g.findSession(bullet.Owner); ps != nil {
+		ps.gainEXP(trap.Attr.EXP)
+	}
+	// remove the trap
+	g.removeTrap(trap)
+}
@@ -1,16 +1,5 @@
 package util
 
-/**
- * Point:
- * The struct to present the point.
- *
- * @property {float64} X 									- the x of the point
- * @property {float64} Y									- the y of the point
- */
-type Point struct {
-	X, Y float64
-}
-
 /**
  * Size:
  * The struct to present the size.
@@ -22,34 +11,6 @@ type Size struct {
 	W, H float64
 }
 
-/**
- * AccelerationFormat:
- * The struct to present the acceleration.
- *
- * @property {float64} Up 								- the up volume of the acceleration
- * @property {float64} Down								- the down volume of the acceleration
- * @property {float64} Left								- the left volume of the acceleration
- * @property {float64} Right							- the right volume of the acceleration
- */
-type AccelerationFormat struct {
-	Up float64
-	Down float64
-	Left float64
-	Right float64
-}
-
-/**
- * VelocityFormat:
- * The struct to present the velocity.
- *
- * @property {float64} X 								- the X volume of the velocity
- * @property {float64} Y								- the Y volume of the velocity
- */
-type VelocityFormat struct {
-	X float64
-	Y float64
-} 
-
 /**
  * MoveDirection:
  * The struct to keep the player current moving direction.
@@ -65,3 +26,27 @@ type VelocityFormat struct {
 	Left bool
 	Right bool
 }
+
+
+/**
+ * <MoveDirection>.Vector:
+ * The function in MoveDirection to get the unit vector of the moving direction, zero if not moving.
+ *
+ * @return {Vec2}
+ */
+func (m MoveDirection) Vector() Vec2 {
+	var direction Vec2
+	if (m.Up) {
+		direction.Y -= 1
+	}
+	if (m.Down) {
+		direction.Y += 1
+	}
+	if (m.Left) {
+		direction.X -= 1
+	}
+	if (m.Right) {
+		direction.X += 1
+	}
+	return direction.Normalize()
+}
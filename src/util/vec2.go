@@ -0,0 +1,132 @@
+package util
+
+import (
+	"math"
+)
+
+/**
+ * Vec2:
+ * The struct to present the 2D vector of position, velocity and acceleration.
+ *
+ * @property {float64} X 									- the x component of the vector
+ * @property {float64} Y									- the y component of the vector
+ */
+type Vec2 struct {
+	X, Y float64
+}
+
+/**
+ * <Vec2>.Add:
+ * The function in Vec2 to add another vector.
+ *
+ * @param {Vec2} v												- the vector to add
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Add(v Vec2) Vec2 {
+	return Vec2 { a.X + v.X, a.Y + v.Y }
+}
+
+/**
+ * <Vec2>.Sub:
+ * The function in Vec2 to subtract another vector.
+ *
+ * @param {Vec2} v												- the vector to subtract
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Sub(v Vec2) Vec2 {
+	return Vec2 { a.X - v.X, a.Y - v.Y }
+}
+
+/**
+ * <Vec2>.Scale:
+ * The function in Vec2 to multiply both components by a scalar.
+ *
+ * @param {float64} s											- the scalar
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Scale(s float64) Vec2 {
+	return Vec2 { a.X * s, a.Y * s }
+}
+
+/**
+ * <Vec2>.Dot:
+ * The function in Vec2 to compute the dot product with another vector.
+ *
+ * @param {Vec2} v												- the other vector
+ *
+ * @return {float64}
+ */
+func (a Vec2) Dot(v Vec2) float64 {
+	return a.X * v.X + a.Y * v.Y
+}
+
+/**
+ * <Vec2>.Length:
+ * The function in Vec2 to compute the length of the vector.
+ *
+ * @return {float64}
+ */
+func (a Vec2) Length() float64 {
+	return math.Sqrt(a.X * a.X + a.Y * a.Y)
+}
+
+/**
+ * <Vec2>.Normalize:
+ * The function in Vec2 to get the unit vector in the same direction, the zero vector stays zero.
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Normalize() Vec2 {
+	var length = a.Length()
+	if (length == 0) {
+		return Vec2 {}
+	}
+	return a.Scale(1 / length)
+}
+
+/**
+ * <Vec2>.Rotate:
+ * The function in Vec2 to rotate the vector counterclockwise.
+ *
+ * @param {float64} radian								- the rotation angle in radian
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Rotate(radian float64) Vec2 {
+	var sin, cos = math.Sincos(radian)
+	return Vec2 { a.X * cos - a.Y * sin, a.X * sin + a.Y * cos }
+}
+
+/**
+ * <Vec2>.Clamp:
+ * The function in Vec2 to clamp both components into the box between min and max.
+ *
+ * @param {Vec2} min											- the lower bound of the components
+ * @param {Vec2} max											- the upper bound of the components
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) Clamp(min, max Vec2) Vec2 {
+	return Vec2 {
+		math.Max(math.Min(a.X, max.X), min.X),
+		math.Max(math.Min(a.Y, max.Y), min.Y),
+	}
+}
+
+/**
+ * <Vec2>.ClampLength:
+ * The function in Vec2 to limit the length of the vector without changing its direction.
+ *
+ * @param {float64} max										- the max length of the vector
+ *
+ * @return {Vec2}
+ */
+func (a Vec2) ClampLength(max float64) Vec2 {
+	if (a.Length() <= max) {
+		return a
+	}
+	return a.Normalize().Scale(max)
+}
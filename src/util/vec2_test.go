@@ -0,0 +1,125 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+// define the tolerance of the float comparison in the tests
+const epsilon = 1e-9
+
+/**
+ * <util>.near:
+ * The function to check if two vectors are equal within the tolerance.
+ *
+ * @param {Vec2} a												- the vector
+ * @param {Vec2} b												- the other vector
+ *
+ * @return {bool}
+ */
+func near (a, b Vec2) bool {
+	return math.Abs(a.X - b.X) <= epsilon && math.Abs(a.Y - b.Y) <= epsilon
+}
+
+/**
+ * <util>.TestNormalize:
+ * The test to check the unit vector, and that the zero vector stays zero instead of NaN.
+ */
+func TestNormalize (t *testing.T) {
+	if got := (Vec2 {}).Normalize(); got != (Vec2 {}) {
+		t.Errorf("zero vector normalized to %v, want zero", got)
+	}
+	var got = (Vec2 { 3, -4 }).Normalize()
+	if (!near(got, Vec2 { 0.6, -0.8 })) {
+		t.Errorf("(3, -4) normalized to %v, want (0.6, -0.8)", got)
+	}
+	if length := got.Length(); math.Abs(length - 1) > epsilon {
+		t.Errorf("normalized length is %v, want 1", length)
+	}
+}
+
+/**
+ * <util>.TestRotate:
+ * The test to check the counterclockwise rotation.
+ */
+func TestRotate (t *testing.T) {
+	var cases = []struct {
+		v Vec2
+		radian float64
+		want Vec2
+	} {
+		{ Vec2 { 1, 0 }, math.Pi / 2, Vec2 { 0, 1 } },
+		{ Vec2 { 1, 0 }, math.Pi, Vec2 { -1, 0 } },
+		{ Vec2 { 0, 2 }, -math.Pi / 2, Vec2 { 2, 0 } },
+		{ Vec2 { 3, 4 }, 0, Vec2 { 3, 4 } },
+	}
+	for _, c := range cases {
+		if got := c.v.Rotate(c.radian); !near(got, c.want) {
+			t.Errorf("%v rotated by %v is %v, want %v", c.v, c.radian, got, c.want)
+		}
+	}
+}
+
+/**
+ * <util>.TestClamp:
+ * The test to check that each component is clamped into the box on its own.
+ */
+func TestClamp (t *testing.T) {
+	var min = Vec2 { 0, 0 }
+	var max = Vec2 { 10, 20 }
+	var cases = []struct {
+		v Vec2
+		want Vec2
+	} {
+		{ Vec2 { 5, 5 }, Vec2 { 5, 5 } },
+		{ Vec2 { -1, 25 }, Vec2 { 0, 20 } },
+		{ Vec2 { 11, -3 }, Vec2 { 10, 0 } },
+	}
+	for _, c := range cases {
+		if got := c.v.Clamp(min, max); got != c.want {
+			t.Errorf("%v clamped to %v, want %v", c.v, got, c.want)
+		}
+	}
+}
+
+/**
+ * <util>.TestClampLength:
+ * The test to check that only the vectors longer than the max are shortened, in the same direction.
+ */
+func TestClampLength (t *testing.T) {
+	if got := (Vec2 { 3, 4 }).ClampLength(10); got != (Vec2 { 3, 4 }) {
+		t.Errorf("short vector changed to %v", got)
+	}
+	if got := (Vec2 { 30, 40 }).ClampLength(5); !near(got, Vec2 { 3, 4 }) {
+		t.Errorf("(30, 40) clamped to %v, want (3, 4)", got)
+	}
+	if got := (Vec2 {}).ClampLength(0); got != (Vec2 {}) {
+		t.Errorf("zero vector clamped to %v, want zero", got)
+	}
+}
+
+/**
+ * <util>.TestDot:
+ * The test to check the dot product.
+ */
+func TestDot (t *testing.T) {
+	if got := (Vec2 { 1, 2 }).Dot(Vec2 { 3, 4 }); got != 11 {
+		t.Errorf("(1, 2) . (3, 4) is %v, want 11", got)
+	}
+	if got := (Vec2 { 1, 0 }).Dot(Vec2 { 0, 5 }); got != 0 {
+		t.Errorf("perpendicular dot is %v, want 0", got)
+	}
+}
+
+/**
+ * <util>.TestLength:
+ * The test to check the vector length.
+ */
+func TestLength (t *testing.T) {
+	if got := (Vec2 { 3, -4 }).Length(); got != 5 {
+		t.Errorf("length of (3, -4) is %v, want 5", got)
+	}
+	if got := (Vec2 {}).Length(); got != 0 {
+		t.Errorf("length of zero vector is %v, want 0", got)
+	}
+}